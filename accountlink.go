@@ -0,0 +1,191 @@
+package social
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Account link constants
+const (
+	APIEndpointAccountLink = "/dialog/bot/accountLink"
+
+	// AccountLinkTTL is the validity of a link token.
+	AccountLinkTTL = 10 * time.Minute
+)
+
+// IssueLinkToken: Issues a link token for the user with the Messaging API.
+// Requires the channel access token of the Messaging API channel.
+// https://developers.line.biz/en/reference/messaging-api/#issue-link-token
+func (client *Client) IssueLinkToken(channelAccessToken, userID string) *IssueLinkTokenCall {
+	return &IssueLinkTokenCall{
+		c:                  client,
+		channelAccessToken: channelAccessToken,
+		userID:             userID,
+	}
+}
+
+// IssueLinkTokenCall type
+type IssueLinkTokenCall struct {
+	c   *Client
+	ctx context.Context
+
+	channelAccessToken string
+	userID             string
+}
+
+// WithContext method
+func (call *IssueLinkTokenCall) WithContext(ctx context.Context) *IssueLinkTokenCall {
+	call.ctx = ctx
+	return call
+}
+
+// Do method
+func (call *IssueLinkTokenCall) Do() (*IssueLinkTokenResponse, error) {
+	endpoint := "/v2/bot/user/" + url.PathEscape(call.userID) + "/linkToken"
+	res, err := call.c.postWithBearerAuth(call.ctx, endpoint, call.channelAccessToken, nil)
+	if res != nil && res.Body != nil {
+		defer res.Body.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := checkResponse(res); err != nil {
+		return nil, err
+	}
+	result := IssueLinkTokenResponse{}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// IssueLinkTokenResponse type
+type IssueLinkTokenResponse struct {
+	LinkToken string `json:"linkToken"`
+}
+
+// AccountLinkURL returns the URL the user opens to link their LINE account.
+func AccountLinkURL(linkToken, nonce string) string {
+	u, _ := url.Parse(APIEndpointAuthBase + APIEndpointAccountLink)
+	q := u.Query()
+	q.Set("linkToken", linkToken)
+	q.Set("nonce", nonce)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// AccountLinkNonce binds an account link nonce to the accounts being linked.
+type AccountLinkNonce struct {
+	Nonce      string    `json:"nonce"`
+	SiteUserID string    `json:"site_user_id"`
+	LINEUserID string    `json:"line_user_id"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// AccountLinkStore keeps account link nonces until the accountLink webhook
+// event arrives.
+type AccountLinkStore interface {
+	// Save stores n under n.Nonce.
+	Save(ctx context.Context, n *AccountLinkNonce) error
+	// Consume returns and deletes the nonce. It returns
+	// ErrAccountLinkNonceNotFound when the nonce is unknown, already
+	// consumed or expired.
+	Consume(ctx context.Context, nonce string) (*AccountLinkNonce, error)
+}
+
+// AccountLink is a confirmed link between a site account and a LINE user.
+type AccountLink struct {
+	SiteUserID string
+	LINEUserID string
+}
+
+// BeginAccountLink issues a link token for lineUserID, stores a new nonce
+// bound to siteUserID and returns the URL to send the user to. Call it when
+// a user who talks to your bot has signed in to your site.
+func (client *Client) BeginAccountLink(ctx context.Context, store AccountLinkStore, channelAccessToken, lineUserID, siteUserID string) (string, error) {
+	token, err := client.IssueLinkToken(channelAccessToken, lineUserID).WithContext(ctx).Do()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := GenerateNonce()
+	if err != nil {
+		return "", err
+	}
+	err = store.Save(ctx, &AccountLinkNonce{
+		Nonce:      nonce,
+		SiteUserID: siteUserID,
+		LINEUserID: lineUserID,
+		ExpiresAt:  time.Now().Add(AccountLinkTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+	return AccountLinkURL(token.LinkToken, nonce), nil
+}
+
+// CompleteAccountLink consumes the nonce of an accountLink webhook event and
+// returns the accounts to link. It fails with ErrAccountLinkFailed when
+// result is not "ok" and with ErrAccountLinkUserMismatch when the event's
+// user is not the one the link token was issued for.
+func CompleteAccountLink(ctx context.Context, store AccountLinkStore, lineUserID, result, nonce string) (*AccountLink, error) {
+	n, err := store.Consume(ctx, nonce)
+	if err != nil {
+		return nil, err
+	}
+	if result != "ok" {
+		return nil, fmt.Errorf("%w: result %q", ErrAccountLinkFailed, result)
+	}
+	if n.LINEUserID != lineUserID {
+		return nil, ErrAccountLinkUserMismatch
+	}
+	return &AccountLink{SiteUserID: n.SiteUserID, LINEUserID: n.LINEUserID}, nil
+}
+
+// MemoryAccountLinkStore is an AccountLinkStore for a single process.
+type MemoryAccountLinkStore struct {
+	mu     sync.Mutex
+	nonces map[string]*AccountLinkNonce
+	now    func() time.Time
+}
+
+// NewMemoryAccountLinkStore returns an empty MemoryAccountLinkStore.
+func NewMemoryAccountLinkStore() *MemoryAccountLinkStore {
+	return &MemoryAccountLinkStore{
+		nonces: map[string]*AccountLinkNonce{},
+		now:    time.Now,
+	}
+}
+
+// Save method
+func (m *MemoryAccountLinkStore) Save(ctx context.Context, n *AccountLinkNonce) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	for key, saved := range m.nonces {
+		if !now.Before(saved.ExpiresAt) {
+			delete(m.nonces, key)
+		}
+	}
+	saved := *n
+	m.nonces[n.Nonce] = &saved
+	return nil
+}
+
+// Consume method
+func (m *MemoryAccountLinkStore) Consume(ctx context.Context, nonce string) (*AccountLinkNonce, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n, ok := m.nonces[nonce]
+	if !ok {
+		return nil, ErrAccountLinkNonceNotFound
+	}
+	delete(m.nonces, nonce)
+	if !m.now().Before(n.ExpiresAt) {
+		return nil, ErrAccountLinkNonceNotFound
+	}
+	return n, nil
+}
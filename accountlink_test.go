@@ -0,0 +1,79 @@
+package social
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type endpointMetrics struct {
+	endpoints []string
+}
+
+func (m *endpointMetrics) ObserveRequest(channelID, endpoint string, statusCode int, err error, duration time.Duration) {
+	m.endpoints = append(m.endpoints, endpoint)
+}
+
+func TestAccountLink(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v2/bot/user/U1234/linkToken" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer channel-token" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		fmt.Fprint(w, `{"linkToken":"NMZTNuVrPTqlr2IF8Bnymkb7rXfYv5EY"}`)
+	}))
+	defer srv.Close()
+	metrics := &endpointMetrics{}
+	client, err := New("1234", "secret", WithEndpointBase(srv.URL), WithMetrics(metrics))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewMemoryAccountLinkStore()
+	ctx := context.Background()
+
+	link, err := client.BeginAccountLink(ctx, store, "channel-token", "U1234", "site-42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(link, APIEndpointAuthBase+APIEndpointAccountLink+"?") {
+		t.Errorf("link = %s", link)
+	}
+	u, _ := url.Parse(link)
+	nonce := u.Query().Get("nonce")
+	if u.Query().Get("linkToken") != "NMZTNuVrPTqlr2IF8Bnymkb7rXfYv5EY" || len(nonce) < 10 {
+		t.Errorf("link query = %v", u.Query())
+	}
+	if len(metrics.endpoints) != 1 || metrics.endpoints[0] != "/v2/bot/user/{userId}/linkToken" {
+		t.Errorf("metrics endpoints = %v", metrics.endpoints)
+	}
+
+	// The event must come from the user the link token was issued for.
+	if _, err := CompleteAccountLink(ctx, store, "U9999", "ok", nonce); !errors.Is(err, ErrAccountLinkUserMismatch) {
+		t.Errorf("CompleteAccountLink(other user) = %v", err)
+	}
+	// The nonce was consumed by the failed attempt.
+	if _, err := CompleteAccountLink(ctx, store, "U1234", "ok", nonce); !errors.Is(err, ErrAccountLinkNonceNotFound) {
+		t.Errorf("CompleteAccountLink(reused nonce) = %v", err)
+	}
+
+	link, _ = client.BeginAccountLink(ctx, store, "channel-token", "U1234", "site-42")
+	u, _ = url.Parse(link)
+	got, err := CompleteAccountLink(ctx, store, "U1234", "ok", u.Query().Get("nonce"))
+	if err != nil || got.SiteUserID != "site-42" || got.LINEUserID != "U1234" {
+		t.Errorf("CompleteAccountLink = %+v, %v", got, err)
+	}
+
+	link, _ = client.BeginAccountLink(ctx, store, "channel-token", "U1234", "site-42")
+	u, _ = url.Parse(link)
+	if _, err := CompleteAccountLink(ctx, store, "U1234", "failed", u.Query().Get("nonce")); !errors.Is(err, ErrAccountLinkFailed) {
+		t.Errorf("CompleteAccountLink(failed) = %v", err)
+	}
+}
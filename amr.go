@@ -0,0 +1,106 @@
+package social
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Authentication methods in the amr claim of an ID token
+// https://developers.line.biz/en/docs/line-login/verify-id-token/#payload
+const (
+	AMRPassword    = "pwd"
+	AMRAutoLogin   = "lineautologin"
+	AMRQRCode      = "lineqr"
+	AMRSSO         = "linesso"
+	AMRMultiFactor = "mfa"
+)
+
+// Step-up failure reasons
+const (
+	StepUpMethod = "method"
+	StepUpAge    = "age"
+)
+
+// AuthPolicy describes how recently and how strongly a user must have
+// authenticated, e.g. before a sensitive action.
+type AuthPolicy struct {
+	// Methods lists the accepted authentication methods; the ID token must
+	// name at least one of them. Empty accepts any method.
+	Methods []string
+	// MaxAge is the longest accepted time since auth_time. 0 accepts any age.
+	MaxAge time.Duration
+	// Clock returns the current time. Default time.Now.
+	Clock func() time.Time
+}
+
+// StepUpError is returned when an ID token does not satisfy an AuthPolicy.
+// It matches ErrStepUpRequired with errors.Is.
+type StepUpError struct {
+	// Reason is StepUpMethod or StepUpAge.
+	Reason   string
+	AMR      []string
+	AuthTime time.Time
+}
+
+// Error method
+func (e *StepUpError) Error() string {
+	if e.Reason == StepUpAge {
+		return fmt.Sprintf("%s: authenticated at %s", ErrStepUpRequired, e.AuthTime.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("%s: authenticated with %q", ErrStepUpRequired, strings.Join(e.AMR, " "))
+}
+
+// Is method
+func (e *StepUpError) Is(target error) bool {
+	return target == ErrStepUpRequired
+}
+
+// Check returns a *StepUpError when the ID token payload does not satisfy
+// the policy.
+func (p *AuthPolicy) Check(payload *BasicPayload) error {
+	return p.check(payload.Amr, payload.AuthTime)
+}
+
+// CheckVerified is Check for a VerifyIDToken response.
+func (p *AuthPolicy) CheckVerified(res *VerifyIDTokenResponse) error {
+	return p.check(res.Amr, res.AuthTime)
+}
+
+func (p *AuthPolicy) check(amr []string, authTime int) error {
+	at := time.Unix(int64(authTime), 0)
+	if len(p.Methods) > 0 && !slices.ContainsFunc(amr, func(m string) bool { return slices.Contains(p.Methods, m) }) {
+		return &StepUpError{Reason: StepUpMethod, AMR: amr, AuthTime: at}
+	}
+	if p.MaxAge > 0 {
+		now := time.Now
+		if p.Clock != nil {
+			now = p.Clock
+		}
+		if authTime == 0 || now().Sub(at) > p.MaxAge {
+			return &StepUpError{Reason: StepUpAge, AMR: amr, AuthTime: at}
+		}
+	}
+	return nil
+}
+
+// RequestOptions returns options for an authorization request that makes
+// the user authenticate again under the policy: prompt=login, max_age and,
+// unless auto login or SSO is accepted, disable_auto_login. Pass them to
+// GetWebLoinURL, GetPKCEWebLoinURL or BeginLogin.
+func (p *AuthPolicy) RequestOptions(options AuthRequestOptions) AuthRequestOptions {
+	options.Prompt = PromptLogin
+	if p.MaxAge > 0 {
+		options.MaxAge = int(p.MaxAge / time.Second)
+	}
+	if len(p.Methods) > 0 && !slices.Contains(p.Methods, AMRAutoLogin) && !slices.Contains(p.Methods, AMRSSO) {
+		options.DisableAutoLogin = true
+	}
+	return options
+}
+
+// ReauthURL returns the authorization URL for RequestOptions.
+func (client *Client) ReauthURL(redirectURL, state, scope string, policy *AuthPolicy, options AuthRequestOptions) (string, error) {
+	return client.GetWebLoinURL(redirectURL, state, scope, policy.RequestOptions(options))
+}
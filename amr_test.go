@@ -0,0 +1,74 @@
+package social
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestAuthPolicy(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	policy := &AuthPolicy{
+		Methods: []string{AMRPassword, AMRQRCode},
+		MaxAge:  5 * time.Minute,
+		Clock:   func() time.Time { return now },
+	}
+	recent := int(now.Add(-time.Minute).Unix())
+	stale := int(now.Add(-10 * time.Minute).Unix())
+
+	tests := []struct {
+		amr      []string
+		authTime int
+		reason   string
+	}{
+		{[]string{AMRPassword}, recent, ""},
+		{[]string{AMRQRCode, AMRMultiFactor}, recent, ""},
+		{[]string{AMRAutoLogin}, recent, StepUpMethod},
+		{[]string{AMRSSO}, recent, StepUpMethod},
+		{nil, recent, StepUpMethod},
+		{[]string{AMRPassword}, stale, StepUpAge},
+		{[]string{AMRPassword}, 0, StepUpAge},
+	}
+	for _, tt := range tests {
+		err := policy.Check(&BasicPayload{Amr: tt.amr, AuthTime: tt.authTime})
+		if tt.reason == "" {
+			if err != nil {
+				t.Errorf("Check(%v, %d) = %v", tt.amr, tt.authTime, err)
+			}
+			continue
+		}
+		var stepUp *StepUpError
+		if !errors.As(err, &stepUp) || stepUp.Reason != tt.reason || !errors.Is(err, ErrStepUpRequired) {
+			t.Errorf("Check(%v, %d) = %v, want reason %s", tt.amr, tt.authTime, err, tt.reason)
+		}
+	}
+	if err := policy.CheckVerified(&VerifyIDTokenResponse{Amr: []string{AMRAutoLogin}, AuthTime: recent}); !errors.Is(err, ErrStepUpRequired) {
+		t.Errorf("CheckVerified = %v", err)
+	}
+	if err := (&AuthPolicy{}).Check(&BasicPayload{}); err != nil {
+		t.Errorf("empty policy: %v", err)
+	}
+}
+
+func TestReauthURL(t *testing.T) {
+	client, err := New("1234", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := &AuthPolicy{Methods: []string{AMRPassword, AMRQRCode}, MaxAge: 5 * time.Minute}
+	authURL, err := client.ReauthURL("https://example.com/callback", "state", "openid", policy, AuthRequestOptions{Nonce: "n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(authURL)
+	q := u.Query()
+	if q.Get("prompt") != PromptLogin || q.Get("max_age") != "300" || q.Get("disable_auto_login") != "true" || q.Get("nonce") != "n" {
+		t.Errorf("query = %v", q)
+	}
+
+	options := (&AuthPolicy{Methods: []string{AMRPassword, AMRSSO}}).RequestOptions(AuthRequestOptions{})
+	if options.DisableAutoLogin || options.MaxAge != 0 {
+		t.Errorf("RequestOptions with SSO = %+v", options)
+	}
+}
@@ -0,0 +1,84 @@
+package social
+
+import (
+	"context"
+)
+
+// LoginAPI is the context-first view of the LINE Login API. *Client does not
+// implement it; get one with Client.API. Code that depends on LoginAPI rather
+// than *Client can substitute a fake in unit tests; see the socialtest package.
+type LoginAPI interface {
+	// ExchangeCode issues an access token for an authorization code. When
+	// codeVerifier is not empty the PKCE flow is used.
+	ExchangeCode(ctx context.Context, redirectURL, code, codeVerifier string) (*TokenResponse, error)
+	// Refresh gets a new access token using a refresh token.
+	Refresh(ctx context.Context, refreshToken string) (*TokenRefreshResponse, error)
+	// Verify verifies an access token.
+	Verify(ctx context.Context, accessToken string) (*TokenVerifyResponse, error)
+	// Revoke invalidates an access token.
+	Revoke(ctx context.Context, accessToken string) (*BasicResponse, error)
+	// Profile gets the user's display name, profile image and status message.
+	Profile(ctx context.Context, accessToken string) (*GetUserProfileResponse, error)
+	// UserInfo calls the OIDC userinfo endpoint.
+	UserInfo(ctx context.Context, accessToken string) (*GetUserInfoResponse, error)
+	// Friendship gets the friendship status with the linked LINE Official Account.
+	Friendship(ctx context.Context, accessToken string) (*GetFriendshipStatusResponse, error)
+	// Deauthorize revokes all permissions granted by the user.
+	Deauthorize(ctx context.Context, channelAccessToken, userAccessToken string) (*BasicResponse, error)
+	// VerifyIDToken verifies an ID token with the LINE Platform.
+	VerifyIDToken(ctx context.Context, idToken string, options VerifyIDTokenRequestOptions) (*VerifyIDTokenResponse, error)
+}
+
+// API returns the LoginAPI backed by client.
+//
+// Client keeps its builder style methods (GetAccessToken, Deauthorize,
+// VerifyIDToken, ...), so the context-first methods live on this view
+// instead of on *Client itself.
+func (client *Client) API() LoginAPI {
+	return clientAPI{c: client}
+}
+
+type clientAPI struct {
+	c *Client
+}
+
+var _ LoginAPI = clientAPI{}
+
+func (api clientAPI) ExchangeCode(ctx context.Context, redirectURL, code, codeVerifier string) (*TokenResponse, error) {
+	if codeVerifier != "" {
+		return api.c.GetAccessTokenPKCE(redirectURL, code, codeVerifier).WithContext(ctx).Do()
+	}
+	return api.c.GetAccessToken(redirectURL, code).WithContext(ctx).Do()
+}
+
+func (api clientAPI) Refresh(ctx context.Context, refreshToken string) (*TokenRefreshResponse, error) {
+	return api.c.RefreshToken(refreshToken).WithContext(ctx).Do()
+}
+
+func (api clientAPI) Verify(ctx context.Context, accessToken string) (*TokenVerifyResponse, error) {
+	return api.c.TokenVerify(accessToken).WithContext(ctx).Do()
+}
+
+func (api clientAPI) Revoke(ctx context.Context, accessToken string) (*BasicResponse, error) {
+	return api.c.RevokeToken(accessToken).WithContext(ctx).Do()
+}
+
+func (api clientAPI) Profile(ctx context.Context, accessToken string) (*GetUserProfileResponse, error) {
+	return api.c.GetUserProfile(accessToken).WithContext(ctx).Do()
+}
+
+func (api clientAPI) UserInfo(ctx context.Context, accessToken string) (*GetUserInfoResponse, error) {
+	return api.c.GetUserInfo(accessToken).WithContext(ctx).Do()
+}
+
+func (api clientAPI) Friendship(ctx context.Context, accessToken string) (*GetFriendshipStatusResponse, error) {
+	return api.c.GetFriendshipStatus(accessToken).WithContext(ctx).Do()
+}
+
+func (api clientAPI) Deauthorize(ctx context.Context, channelAccessToken, userAccessToken string) (*BasicResponse, error) {
+	return api.c.Deauthorize(channelAccessToken, userAccessToken).WithContext(ctx).Do()
+}
+
+func (api clientAPI) VerifyIDToken(ctx context.Context, idToken string, options VerifyIDTokenRequestOptions) (*VerifyIDTokenResponse, error) {
+	return api.c.VerifyIDToken(idToken, options).WithContext(ctx).Do()
+}
@@ -0,0 +1,274 @@
+package social
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Principal is the LINE user behind a verified access token.
+type Principal struct {
+	UserID    string
+	Name      string
+	Picture   string
+	ClientID  string
+	Scopes    []string
+	ExpiresAt time.Time
+}
+
+// HasScope reports whether the access token was granted scope.
+func (p *Principal) HasScope(scope string) bool {
+	return NewScopeSet(p.Scopes...).Has(scope)
+}
+
+type principalKey struct{}
+
+// NewContext returns a copy of ctx carrying p.
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal stored by BearerAuth.Middleware.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
+
+// MissingScopeError is returned when a token lacks required scopes. It
+// matches ErrMissingScope with errors.Is.
+type MissingScopeError struct {
+	Missing []string
+}
+
+// Error method
+func (e *MissingScopeError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrMissingScope, strings.Join(e.Missing, " "))
+}
+
+// Is method
+func (e *MissingScopeError) Is(target error) bool {
+	return target == ErrMissingScope
+}
+
+// BearerAuthOptions configures BearerAuth.
+type BearerAuthOptions struct {
+	// AllowedClientIDs lists the channels whose tokens are accepted. The
+	// client's own channel is used when empty.
+	AllowedClientIDs []string
+	// RequiredScopes must all have been granted to the token. "openid" or
+	// "profile" is needed to resolve the user ID.
+	RequiredScopes []string
+	// MaxCacheEntries bounds the verification cache. Default 10000.
+	MaxCacheEntries int
+	// MaxCacheTTL bounds how long a verified token is trusted without asking
+	// LINE again, so a revoked token is rejected soon after. Default 5
+	// minutes; negative disables the cache.
+	MaxCacheTTL time.Duration
+	// ErrorHandler writes the response for a rejected request. The default
+	// writes a WWW-Authenticate header and a plain status.
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// BearerAuth authenticates requests carrying a LINE access token in the
+// Authorization header. Verified tokens are cached, keyed by their SHA-256
+// hash, until they expire or MaxCacheTTL passes.
+type BearerAuth struct {
+	client  *Client
+	options BearerAuthOptions
+	now     func() time.Time
+
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte]principalEntry
+}
+
+type principalEntry struct {
+	principal *Principal
+	expires   time.Time
+}
+
+// BearerAuth returns an authenticator that verifies access tokens with this client.
+func (client *Client) BearerAuth(options BearerAuthOptions) *BearerAuth {
+	if len(options.AllowedClientIDs) == 0 {
+		options.AllowedClientIDs = []string{client.channelID}
+	}
+	if options.MaxCacheEntries <= 0 {
+		options.MaxCacheEntries = 10000
+	}
+	if options.MaxCacheTTL == 0 {
+		options.MaxCacheTTL = 5 * time.Minute
+	}
+	return &BearerAuth{
+		client:  client,
+		options: options,
+		now:     time.Now,
+		cache:   map[[sha256.Size]byte]principalEntry{},
+	}
+}
+
+// Authenticate verifies accessToken and returns its principal. It returns
+// ErrInvalidToken when LINE rejects the token as invalid or expired,
+// ErrClientNotAllowed for tokens of other channels, a *MissingScopeError
+// when required scopes were not granted and ErrUnknownUser when neither
+// openid nor profile was granted. Each call returns a new Principal.
+func (a *BearerAuth) Authenticate(ctx context.Context, accessToken string) (*Principal, error) {
+	if accessToken == "" {
+		return nil, ErrMissingToken
+	}
+	key := sha256.Sum256([]byte(accessToken))
+	now := a.now()
+	a.mu.Lock()
+	entry, ok := a.cache[key]
+	if ok && !now.Before(entry.expires) {
+		delete(a.cache, key)
+		ok = false
+	}
+	a.mu.Unlock()
+	if ok {
+		return entry.principal.clone(), nil
+	}
+
+	verified, err := a.client.TokenVerify(accessToken).WithContext(ctx).Do()
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && (apiErr.Code == http.StatusUnauthorized ||
+			apiErr.Code == http.StatusBadRequest && invalidUserToken(apiErr.Response)) {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+		}
+		return nil, err
+	}
+	if !a.allowed(verified.ClientID) {
+		return nil, fmt.Errorf("%w: %s", ErrClientNotAllowed, verified.ClientID)
+	}
+	scopes := verified.Scopes()
+	if missing := scopes.Missing(a.options.RequiredScopes...); len(missing) > 0 {
+		return nil, &MissingScopeError{Missing: missing}
+	}
+	p := &Principal{
+		ClientID:  verified.ClientID,
+		Scopes:    scopes.Slice(),
+		ExpiresAt: now.Add(time.Duration(verified.ExpiresIn) * time.Second),
+	}
+	switch {
+	case scopes.Has(ScopeOpenID):
+		info, err := a.client.GetUserInfo(accessToken).WithContext(ctx).Do()
+		if err != nil {
+			return nil, err
+		}
+		p.UserID, p.Name, p.Picture = info.Sub, info.Name, info.Picture
+	case scopes.Has(ScopeProfile):
+		profile, err := a.client.GetUserProfile(accessToken).WithContext(ctx).Do()
+		if err != nil {
+			return nil, err
+		}
+		p.UserID, p.Name, p.Picture = profile.UserID, profile.DisplayName, profile.PictureURL
+	default:
+		return nil, ErrUnknownUser
+	}
+
+	if a.options.MaxCacheTTL > 0 {
+		expires := now.Add(a.options.MaxCacheTTL)
+		if p.ExpiresAt.Before(expires) {
+			expires = p.ExpiresAt
+		}
+		a.mu.Lock()
+		if len(a.cache) >= a.options.MaxCacheEntries {
+			a.evict(now)
+		}
+		a.cache[key] = principalEntry{principal: p.clone(), expires: expires}
+		a.mu.Unlock()
+	}
+	return p, nil
+}
+
+func (p *Principal) clone() *Principal {
+	c := *p
+	c.Scopes = slices.Clone(p.Scopes)
+	return &c
+}
+
+func (a *BearerAuth) allowed(clientID string) bool {
+	for _, id := range a.options.AllowedClientIDs {
+		if id == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+// evict removes expired entries, and then arbitrary ones, until there is
+// room for one more. It must be called with a.mu held.
+func (a *BearerAuth) evict(now time.Time) {
+	for key, entry := range a.cache {
+		if !now.Before(entry.expires) {
+			delete(a.cache, key)
+		}
+	}
+	for key := range a.cache {
+		if len(a.cache) < a.options.MaxCacheEntries {
+			break
+		}
+		delete(a.cache, key)
+	}
+}
+
+// Middleware rejects requests without a valid bearer token and passes the
+// others to next with the principal in the request context.
+func (a *BearerAuth) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, err := a.Authenticate(r.Context(), bearerToken(r))
+		if err != nil {
+			if a.options.ErrorHandler != nil {
+				a.options.ErrorHandler(w, r, err)
+			} else {
+				writeBearerError(w, err)
+			}
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), p)))
+	})
+}
+
+func bearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// writeBearerError answers with the status and WWW-Authenticate header of RFC
+// 6750. Only tokens LINE rejected are reported as invalid; a rate limit is
+// answered with 503 and other upstream failures with 502, so clients keep
+// their tokens.
+func writeBearerError(w http.ResponseWriter, err error) {
+	var apiErr *APIError
+	switch {
+	case errors.Is(err, ErrMissingToken):
+		w.Header().Set("WWW-Authenticate", `Bearer`)
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+	case errors.Is(err, ErrMissingScope):
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope=%q`, JoinScopes(errorScopes(err)...)))
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+	case errors.Is(err, ErrUnknownUser):
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope=%q`, ScopeOpenID))
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+	case errors.Is(err, ErrInvalidToken), errors.Is(err, ErrClientNotAllowed):
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+	case errors.As(err, &apiErr) && apiErr.Code == http.StatusTooManyRequests:
+		retryAfter := apiErr.Header.Get("Retry-After")
+		if retryAfter == "" {
+			retryAfter = "5"
+		}
+		w.Header().Set("Retry-After", retryAfter)
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+	default:
+		http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+	}
+}
@@ -0,0 +1,172 @@
+package social
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newAuthServer serves TokenVerify, userinfo and the profile. Tokens are
+// "good", "long-lived", "other-channel", "no-openid", "no-user",
+// "rate-limited" and "userinfo-fails"; anything else is rejected.
+func newAuthServer(t *testing.T, calls *int) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case APIEndpointTokenVerify:
+			*calls++
+			switch r.URL.Query().Get("access_token") {
+			case "good":
+				fmt.Fprint(w, `{"scope":"profile openid","client_id":"1234","expires_in":60}`)
+			case "long-lived":
+				fmt.Fprint(w, `{"scope":"profile openid","client_id":"1234","expires_in":2592000}`)
+			case "rate-limited":
+				w.Header().Set("Retry-After", "30")
+				w.WriteHeader(http.StatusTooManyRequests)
+				fmt.Fprint(w, `{"message":"Too many requests"}`)
+			case "userinfo-fails":
+				fmt.Fprint(w, `{"scope":"profile openid","client_id":"1234","expires_in":60}`)
+			case "no-user":
+				fmt.Fprint(w, `{"scope":"chat_message.write","client_id":"1234","expires_in":60}`)
+			case "other-channel":
+				fmt.Fprint(w, `{"scope":"profile openid","client_id":"9999","expires_in":60}`)
+			case "no-openid":
+				fmt.Fprint(w, `{"scope":"profile","client_id":"1234","expires_in":60}`)
+			default:
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"error":"invalid_request","error_description":"access token expired"}`)
+			}
+		case APIEndpointUserInfo:
+			if r.Header.Get("Authorization") == "Bearer userinfo-fails" {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"message":"Not found"}`)
+				return
+			}
+			fmt.Fprint(w, `{"sub":"U1234","name":"Brown"}`)
+		case APIEndpointGetUserProfile:
+			fmt.Fprint(w, `{"userId":"U1234","displayName":"Brown (profile)"}`)
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestBearerAuthMiddleware(t *testing.T) {
+	var calls int
+	srv := newAuthServer(t, &calls)
+	client, err := New("1234", "secret", WithEndpointBase(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := client.BearerAuth(BearerAuthOptions{RequiredScopes: []string{"openid"}})
+	now := time.Now()
+	auth.now = func() time.Time { return now }
+
+	handler := auth.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := FromContext(r.Context())
+		if !ok {
+			t.Fatal("no principal in context")
+		}
+		fmt.Fprint(w, p.UserID, " ", p.Name)
+	}))
+
+	tests := []struct {
+		header string
+		status int
+		body   string
+	}{
+		{"Bearer good", http.StatusOK, "U1234 Brown"},
+		{"", http.StatusUnauthorized, ""},
+		{"Basic Zm9vOmJhcg==", http.StatusUnauthorized, ""},
+		{"Bearer expired", http.StatusUnauthorized, ""},
+		{"Bearer other-channel", http.StatusUnauthorized, ""},
+		{"Bearer no-openid", http.StatusForbidden, ""},
+		// Upstream failures do not invalidate the client's token.
+		{"Bearer rate-limited", http.StatusServiceUnavailable, ""},
+		{"Bearer userinfo-fails", http.StatusBadGateway, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api", nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.status {
+			t.Errorf("%q: status = %d, want %d", tt.header, rec.Code, tt.status)
+		}
+		if tt.body != "" && rec.Body.String() != tt.body {
+			t.Errorf("%q: body = %q, want %q", tt.header, rec.Body.String(), tt.body)
+		}
+		if authenticate := rec.Header().Get("WWW-Authenticate"); (tt.status == http.StatusUnauthorized || tt.status == http.StatusForbidden) != (authenticate != "") {
+			t.Errorf("%q: WWW-Authenticate = %q", tt.header, authenticate)
+		}
+		if tt.status == http.StatusServiceUnavailable && rec.Header().Get("Retry-After") != "30" {
+			t.Errorf("%q: Retry-After = %q", tt.header, rec.Header().Get("Retry-After"))
+		}
+	}
+
+	// The verified token is cached until expires_in runs out.
+	calls = 0
+	for i := 0; i < 3; i++ {
+		if _, err := auth.Authenticate(context.Background(), "good"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 0 {
+		t.Errorf("verify calls with cache = %d, want 0", calls)
+	}
+	now = now.Add(time.Minute)
+	if _, err := auth.Authenticate(context.Background(), "good"); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("verify calls after expiry = %d, want 1", calls)
+	}
+
+	// A long-lived token is verified again after MaxCacheTTL.
+	calls = 0
+	p, err := auth.Authenticate(context.Background(), "long-lived")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.UserID, p.Scopes[0] = "U9999", "tampered"
+	now = now.Add(4 * time.Minute)
+	if p, err := auth.Authenticate(context.Background(), "long-lived"); err != nil || p.UserID != "U1234" || p.Scopes[0] == "tampered" || calls != 1 {
+		t.Errorf("cached principal = %+v, %v, calls = %d", p, err, calls)
+	}
+	now = now.Add(2 * time.Minute)
+	if _, err := auth.Authenticate(context.Background(), "long-lived"); err != nil || calls != 2 {
+		t.Errorf("verify calls after MaxCacheTTL = %d, %v", calls, err)
+	}
+}
+
+func TestBearerAuthWithoutOpenID(t *testing.T) {
+	var calls int
+	srv := newAuthServer(t, &calls)
+	client, err := New("1234", "secret", WithEndpointBase(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := client.BearerAuth(BearerAuthOptions{MaxCacheTTL: -1})
+	p, err := auth.Authenticate(context.Background(), "no-openid")
+	if err != nil || p.UserID != "U1234" || p.Name != "Brown (profile)" {
+		t.Errorf("Authenticate(profile only) = %+v, %v", p, err)
+	}
+	if _, err := auth.Authenticate(context.Background(), "no-openid"); err != nil || calls != 2 {
+		t.Errorf("verify calls without cache = %d, %v", calls, err)
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api", nil)
+	req.Header.Set("Authorization", "Bearer no-user")
+	auth.Middleware(http.NotFoundHandler()).ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden || rec.Header().Get("WWW-Authenticate") != `Bearer error="insufficient_scope", scope="openid"` {
+		t.Errorf("no-user: status = %d, WWW-Authenticate = %q", rec.Code, rec.Header().Get("WWW-Authenticate"))
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"time"
 )
 
 // APIEndpoint constants
@@ -27,27 +28,32 @@ const (
 
 // Client type
 type Client struct {
-	channelID     string
-	channelSecret string
-	endpointBase  *url.URL     // default APIEndpointBase
-	httpClient    *http.Client // default http.DefaultClient
+	channelID    string
+	secrets      SecretProvider // channel secret
+	endpointBase *url.URL       // default APIEndpointBase
+	httpClient   *http.Client   // default http.DefaultClient
+
+	retry          RetryPolicy     // default no retries
+	metrics        Metrics         // nil disables metrics
+	redirectPolicy *RedirectPolicy // nil allows any redirect URL
+	replayGuard    ReplayGuard     // nil disables replay checks
 }
 
 // ClientOption type
 type ClientOption func(*Client) error
 
-// New returns a new bot client instance.
+// New returns a new bot client instance. channelSecret may be empty when
+// WithSecretProvider is given.
 func New(channelID, channelSecret string, options ...ClientOption) (*Client, error) {
 	if channelID == "" {
 		return nil, errors.New("missing channel ID")
 	}
-	if channelSecret == "" {
-		return nil, errors.New("missing channel secret")
-	}
 	c := &Client{
-		channelID:     channelID,
-		channelSecret: channelSecret,
-		httpClient:    http.DefaultClient,
+		channelID:  channelID,
+		httpClient: http.DefaultClient,
+	}
+	if channelSecret != "" {
+		c.secrets = StaticSecret(channelSecret)
 	}
 	for _, option := range options {
 		err := option(c)
@@ -55,6 +61,9 @@ func New(channelID, channelSecret string, options ...ClientOption) (*Client, err
 			return nil, err
 		}
 	}
+	if c.secrets == nil {
+		return nil, errors.New("missing channel secret")
+	}
 	if c.endpointBase == nil {
 		u, err := url.ParseRequestURI(APIEndpointBase)
 		if err != nil {
@@ -94,19 +103,44 @@ func (client *Client) url(endpoint string) string {
 func (client *Client) do(ctx context.Context, req *http.Request) (*http.Response, error) {
 	req.Header.Set("User-Agent", "API-Service-Go/"+version)
 	if ctx != nil {
-		res, err := client.httpClient.Do(req.WithContext(ctx))
-		if err != nil {
+		req = req.WithContext(ctx)
+	}
+	attempts := client.retry.attempts()
+	if !retryAllowed(ctx, req) {
+		attempts = 1
+	}
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		res, err := client.httpClient.Do(req)
+		if err != nil && ctx != nil {
 			select {
 			case <-ctx.Done():
 				err = ctx.Err()
 			default:
 			}
 		}
+		client.observe(req, res, err, time.Since(start))
 
-		return res, err
+		if attempt >= attempts || !retryable(res, err) || (ctx != nil && ctx.Err() != nil) {
+			return res, err
+		}
+		// A consumed body can only be sent again if it can be recreated.
+		if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+			return res, err
+		}
+		wait := client.retry.delay(attempt, res)
+		discard(res)
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, err
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
 	}
-	return client.httpClient.Do(req)
-
 }
 
 func (client *Client) getHeaderAuth(ctx context.Context, endpoint string, query url.Values) (*http.Response, error) {
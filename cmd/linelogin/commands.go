@@ -0,0 +1,220 @@
+package main
+
+import (
+	social "github.com/kkdai/line-login-sdk-go"
+)
+
+// urlResult is the output of the url command. Keep the state, nonce and code
+// verifier: exchange needs the verifier, and the callback must match the state.
+type urlResult struct {
+	URL          string `json:"url"`
+	State        string `json:"state"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+}
+
+func runURL(env *environment, args []string) error {
+	fs := newFlagSet(env, "url")
+	var redirectURI, scope, state, nonce, codeVerifier string
+	var options social.AuthRequestOptions
+	fs.envString(&redirectURI, "redirect-uri", "LINE_SERVER_URL", "callback URL registered for the channel")
+	fs.StringVar(&scope, "scope", "profile openid", "space separated scopes")
+	fs.StringVar(&state, "state", "", "state value (generated when empty)")
+	fs.StringVar(&nonce, "nonce", "", "nonce value (generated when empty)")
+	fs.StringVar(&codeVerifier, "code-verifier", "", "PKCE code verifier (generated when empty)")
+	fs.StringVar(&options.Prompt, "prompt", "", "prompt parameter, e.g. consent")
+	fs.StringVar(&options.BotPrompt, "bot-prompt", "", "bot_prompt parameter: normal or aggressive")
+	fs.StringVar(&options.UILocales, "ui-locales", "", "ui_locales parameter")
+	if err := fs.parse(args); err != nil {
+		return err
+	}
+	if err := fs.require("redirect-uri"); err != nil {
+		return err
+	}
+	client, err := fs.client()
+	if err != nil {
+		return err
+	}
+
+	if state == "" {
+		if state, err = social.GenerateState(); err != nil {
+			return err
+		}
+	}
+	if nonce == "" {
+		if nonce, err = social.GenerateNonce(); err != nil {
+			return err
+		}
+	}
+	if codeVerifier == "" {
+		if codeVerifier, err = social.GenerateCodeVerifier(43); err != nil {
+			return err
+		}
+	}
+	options.Nonce = nonce
+	u, err := client.GetPKCEWebLoinURL(redirectURI, state, scope, social.PkceChallenge(codeVerifier), options)
+	if err != nil {
+		return err
+	}
+	return fs.print(urlResult{URL: u, State: state, Nonce: nonce, CodeVerifier: codeVerifier})
+}
+
+func runExchange(env *environment, args []string) error {
+	fs := newFlagSet(env, "exchange")
+	var redirectURI, code, codeVerifier string
+	fs.envString(&redirectURI, "redirect-uri", "LINE_SERVER_URL", "callback URL used in the authorize request")
+	fs.envString(&code, "code", "LINE_LOGIN_CODE", "authorization code")
+	fs.StringVar(&codeVerifier, "code-verifier", "", "PKCE code verifier, if the URL was built with PKCE")
+	if err := fs.parse(args); err != nil {
+		return err
+	}
+	if err := fs.require("redirect-uri", "code"); err != nil {
+		return err
+	}
+	client, err := fs.client()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := fs.context()
+	defer cancel()
+	res, err := client.API().ExchangeCode(ctx, redirectURI, code, codeVerifier)
+	if err != nil {
+		return err
+	}
+	return fs.print(res)
+}
+
+func runRefresh(env *environment, args []string) error {
+	fs := newFlagSet(env, "refresh")
+	var refreshToken string
+	fs.credsString(&refreshToken, "refresh-token", "LINE_REFRESH_TOKEN", "refresh token", func(c *credentials) string { return c.RefreshToken })
+	if err := fs.parse(args); err != nil {
+		return err
+	}
+	if err := fs.require("refresh-token"); err != nil {
+		return err
+	}
+	client, err := fs.client()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := fs.context()
+	defer cancel()
+	res, err := client.API().Refresh(ctx, refreshToken)
+	if err != nil {
+		return err
+	}
+	return fs.print(res)
+}
+
+func runVerifyIDToken(env *environment, args []string) error {
+	fs := newFlagSet(env, "verify-id-token")
+	var idToken string
+	var options social.VerifyIDTokenRequestOptions
+	fs.credsString(&idToken, "id-token", "LINE_ID_TOKEN", "ID token", func(c *credentials) string { return c.IDToken })
+	fs.StringVar(&options.Nonce, "nonce", "", "expected nonce")
+	fs.envString(&options.UserID, "user-id", "LINE_USER_ID", "expected user ID")
+	if err := fs.parse(args); err != nil {
+		return err
+	}
+	if err := fs.require("id-token"); err != nil {
+		return err
+	}
+	client, err := fs.client()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := fs.context()
+	defer cancel()
+	res, err := client.API().VerifyIDToken(ctx, idToken, options)
+	if err != nil {
+		return err
+	}
+	return fs.print(res)
+}
+
+func runDeauthorize(env *environment, args []string) error {
+	fs := newFlagSet(env, "deauthorize")
+	var channelAccessToken, accessToken string
+	fs.envString(&channelAccessToken, "channel-access-token", "LINE_CHANNEL_ACCESS_TOKEN", "channel access token")
+	fs.credsString(&accessToken, "access-token", "LINE_ACCESS_TOKEN", "user access token", func(c *credentials) string { return c.AccessToken })
+	if err := fs.parse(args); err != nil {
+		return err
+	}
+	if err := fs.require("channel-access-token", "access-token"); err != nil {
+		return err
+	}
+	client, err := fs.client()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := fs.context()
+	defer cancel()
+	res, err := client.API().Deauthorize(ctx, channelAccessToken, accessToken)
+	if err != nil {
+		return err
+	}
+	return fs.print(res)
+}
+
+func runVerify(env *environment, args []string) error {
+	return runWithAccessToken(env, "verify", args, func(fs *flagSet, api social.LoginAPI, accessToken string) (any, error) {
+		ctx, cancel := fs.context()
+		defer cancel()
+		return api.Verify(ctx, accessToken)
+	})
+}
+
+func runRevoke(env *environment, args []string) error {
+	return runWithAccessToken(env, "revoke", args, func(fs *flagSet, api social.LoginAPI, accessToken string) (any, error) {
+		ctx, cancel := fs.context()
+		defer cancel()
+		return api.Revoke(ctx, accessToken)
+	})
+}
+
+func runProfile(env *environment, args []string) error {
+	return runWithAccessToken(env, "profile", args, func(fs *flagSet, api social.LoginAPI, accessToken string) (any, error) {
+		ctx, cancel := fs.context()
+		defer cancel()
+		return api.Profile(ctx, accessToken)
+	})
+}
+
+func runUserInfo(env *environment, args []string) error {
+	return runWithAccessToken(env, "userinfo", args, func(fs *flagSet, api social.LoginAPI, accessToken string) (any, error) {
+		ctx, cancel := fs.context()
+		defer cancel()
+		return api.UserInfo(ctx, accessToken)
+	})
+}
+
+func runFriendship(env *environment, args []string) error {
+	return runWithAccessToken(env, "friendship", args, func(fs *flagSet, api social.LoginAPI, accessToken string) (any, error) {
+		ctx, cancel := fs.context()
+		defer cancel()
+		return api.Friendship(ctx, accessToken)
+	})
+}
+
+// runWithAccessToken runs the commands that only take a user access token.
+func runWithAccessToken(env *environment, name string, args []string, fn func(fs *flagSet, api social.LoginAPI, accessToken string) (any, error)) error {
+	fs := newFlagSet(env, name)
+	var accessToken string
+	fs.credsString(&accessToken, "access-token", "LINE_ACCESS_TOKEN", "user access token", func(c *credentials) string { return c.AccessToken })
+	if err := fs.parse(args); err != nil {
+		return err
+	}
+	if err := fs.require("access-token"); err != nil {
+		return err
+	}
+	client, err := fs.client()
+	if err != nil {
+		return err
+	}
+	res, err := fn(fs, client.API(), accessToken)
+	if err != nil {
+		return err
+	}
+	return fs.print(res)
+}
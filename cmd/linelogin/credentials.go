@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	social "github.com/kkdai/line-login-sdk-go"
+)
+
+// credentials is the token set written by the login command and read by the
+// other commands when no token is given on the command line.
+type credentials struct {
+	ChannelID    string    `json:"channel_id"`
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	IDToken      string    `json:"id_token,omitempty"`
+	Scope        string    `json:"scope,omitempty"`
+	TokenType    string    `json:"token_type,omitempty"`
+	ObtainedAt   time.Time `json:"obtained_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+func newCredentials(channelID string, token *social.TokenResponse, now time.Time) *credentials {
+	return &credentials{
+		ChannelID:    channelID,
+		AccessToken:  token.AccessToken,
+		RefreshToken: token.RefreshToken,
+		IDToken:      token.IDToken,
+		Scope:        token.Scope,
+		TokenType:    token.TokenType,
+		ObtainedAt:   now.UTC(),
+		ExpiresAt:    now.Add(time.Duration(token.ExpiresIn) * time.Second).UTC(),
+	}
+}
+
+// defaultCredentialsPath returns the credential file under the user config directory.
+func defaultCredentialsPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "linelogin-credentials.json"
+	}
+	return filepath.Join(dir, "linelogin", "credentials.json")
+}
+
+// loadCredentials reads the credential file. A missing file returns nil, nil.
+func loadCredentials(path string) (*credentials, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	creds := &credentials{}
+	if err := json.Unmarshal(data, creds); err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
+// saveCredentials writes the credential file readable by the owner only.
+func saveCredentials(path string, creds *credentials) error {
+	data, err := json.MarshalIndent(creds, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
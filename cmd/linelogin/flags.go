@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	social "github.com/kkdai/line-login-sdk-go"
+)
+
+// errUsage reports a flag error; the flag package has already printed the details.
+var errUsage = errors.New("usage")
+
+// flagSet is a flag.FlagSet whose string flags may fall back to environment
+// variables. Defaults are resolved after parsing so secrets never show up in
+// the -h output.
+type flagSet struct {
+	*flag.FlagSet
+	env       *environment
+	fromEnv   []envFlag
+	fromCreds []credsFlag
+
+	channelID     string
+	channelSecret string
+	endpointBase  string
+	credentials   string
+	output        string
+	timeout       time.Duration
+}
+
+type envFlag struct {
+	p    *string
+	name string
+	key  string
+}
+
+type credsFlag struct {
+	p    *string
+	pick func(*credentials) string
+}
+
+func newFlagSet(env *environment, name string) *flagSet {
+	fs := &flagSet{
+		FlagSet: flag.NewFlagSet("linelogin "+name, flag.ContinueOnError),
+		env:     env,
+	}
+	fs.SetOutput(env.stderr)
+	fs.envString(&fs.channelID, "channel-id", "LINE_CLIENT_ID", "LINE Login channel ID")
+	fs.envString(&fs.channelSecret, "channel-secret", "LINE_CLIENT_SECRET", "LINE Login channel secret")
+	fs.envString(&fs.endpointBase, "endpoint-base", "LINE_ENDPOINT_BASE", "API endpoint base, e.g. a local fake")
+	fs.envString(&fs.credentials, "credentials", "LINE_CREDENTIALS_FILE", "credential file written by the login command")
+	fs.StringVar(&fs.output, "output", "table", "output format: table or json")
+	fs.DurationVar(&fs.timeout, "timeout", 30*time.Second, "request timeout")
+	return fs
+}
+
+// envString defines a string flag that defaults to the envKey environment variable.
+func (fs *flagSet) envString(p *string, name, envKey, usage string) {
+	fs.StringVar(p, name, "", fmt.Sprintf("%s (env %s)", usage, envKey))
+	fs.fromEnv = append(fs.fromEnv, envFlag{p: p, name: name, key: envKey})
+}
+
+// credsString defines a string flag like envString that, when still empty,
+// falls back to the value pick returns from the credential file.
+func (fs *flagSet) credsString(p *string, name, envKey, usage string, pick func(*credentials) string) {
+	fs.envString(p, name, envKey, usage)
+	fs.fromCreds = append(fs.fromCreds, credsFlag{p: p, pick: pick})
+}
+
+func (fs *flagSet) parse(args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(fs.Output(), "unexpected arguments: %v\n", fs.Args())
+		fs.Usage()
+		return errUsage
+	}
+	for _, ef := range fs.fromEnv {
+		if *ef.p == "" {
+			*ef.p = fs.env.getenv(ef.key)
+		}
+	}
+	if fs.credentials == "" {
+		fs.credentials = defaultCredentialsPath()
+	}
+	if err := fs.applyCredentials(); err != nil {
+		return err
+	}
+	if fs.output != "table" && fs.output != "json" {
+		return fmt.Errorf("unknown output format %q", fs.output)
+	}
+	return nil
+}
+
+func (fs *flagSet) applyCredentials() error {
+	var creds *credentials
+	for _, cf := range fs.fromCreds {
+		if *cf.p != "" {
+			continue
+		}
+		if creds == nil {
+			var err error
+			if creds, err = loadCredentials(fs.credentials); err != nil {
+				return err
+			}
+			if creds == nil {
+				return nil
+			}
+		}
+		*cf.p = cf.pick(creds)
+	}
+	return nil
+}
+
+// require returns an error naming the first empty flag in names.
+func (fs *flagSet) require(names ...string) error {
+	for _, name := range names {
+		f := fs.Lookup(name)
+		if f == nil || f.Value.String() != "" {
+			continue
+		}
+		for _, ef := range fs.fromEnv {
+			if ef.name == name {
+				return fmt.Errorf("missing --%s (or %s)", name, ef.key)
+			}
+		}
+		return fmt.Errorf("missing --%s", name)
+	}
+	return nil
+}
+
+func (fs *flagSet) client() (*social.Client, error) {
+	if err := fs.require("channel-id", "channel-secret"); err != nil {
+		return nil, err
+	}
+	var options []social.ClientOption
+	if fs.endpointBase != "" {
+		options = append(options, social.WithEndpointBase(fs.endpointBase))
+	}
+	return social.New(fs.channelID, fs.channelSecret, options...)
+}
+
+func (fs *flagSet) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), fs.timeout)
+}
+
+func (fs *flagSet) print(v any) error {
+	return printResult(fs.env.stdout, fs.output, v)
+}
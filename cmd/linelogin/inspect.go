@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	social "github.com/kkdai/line-login-sdk-go"
+)
+
+// runInspectIDToken decodes and checks an ID token offline. The channel ID
+// and secret are optional here: without them the aud and HS256 signature
+// checks are skipped.
+func runInspectIDToken(env *environment, args []string) error {
+	fs := newFlagSet(env, "inspect-id-token")
+	var idToken, nonce, jwksPath string
+	fs.credsString(&idToken, "id-token", "LINE_ID_TOKEN", "ID token", func(c *credentials) string { return c.IDToken })
+	fs.StringVar(&nonce, "nonce", "", "expected nonce")
+	fs.StringVar(&jwksPath, "jwks", "", "JWKS file used to verify ES256 signatures")
+	if err := fs.parse(args); err != nil {
+		return err
+	}
+	if err := fs.require("id-token"); err != nil {
+		return err
+	}
+
+	opts := social.InspectOptions{
+		ChannelID:     fs.channelID,
+		Nonce:         nonce,
+		ChannelSecret: fs.channelSecret,
+	}
+	if jwksPath != "" {
+		data, err := os.ReadFile(jwksPath)
+		if err != nil {
+			return err
+		}
+		if opts.JWKS, err = social.ParseJWKS(data); err != nil {
+			return err
+		}
+	}
+	ins, err := social.InspectIDToken(idToken, opts)
+	if err != nil {
+		return err
+	}
+
+	if fs.output == "json" {
+		err = fs.print(ins)
+	} else {
+		err = ins.WriteReport(env.stdout)
+	}
+	if err != nil {
+		return err
+	}
+	if n := len(ins.Problems); n > 0 {
+		return fmt.Errorf("%d problem(s) found", n)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"time"
+
+	social "github.com/kkdai/line-login-sdk-go"
+)
+
+// errStateMismatch marks callbacks that do not belong to this login, e.g. a
+// stale browser tab. They are answered but do not end the wait.
+var errStateMismatch = errors.New("state mismatch")
+
+// defaultPort is the login callback port, so the callback URL
+// http://127.0.0.1:8765/callback can be registered for the channel once.
+const defaultPort = 8765
+
+// callbackResult is what the loopback server hands back to the login command.
+type callbackResult struct {
+	code string
+	err  error
+}
+
+// runLogin runs a PKCE login through a temporary 127.0.0.1 callback server
+// and stores the resulting tokens in the credential file.
+func runLogin(env *environment, args []string) error {
+	fs := newFlagSet(env, "login")
+	var scope, callbackPath string
+	var port int
+	var noBrowser bool
+	var wait time.Duration
+	var options social.AuthRequestOptions
+	fs.StringVar(&scope, "scope", "profile openid", "space separated scopes")
+	fs.IntVar(&port, "port", defaultPort, "callback port on 127.0.0.1; http://127.0.0.1:<port><callback-path> must be registered for the channel")
+	fs.StringVar(&callbackPath, "callback-path", "/callback", "callback path")
+	fs.BoolVar(&noBrowser, "no-browser", false, "print the authorize URL instead of opening a browser")
+	fs.DurationVar(&wait, "wait", 5*time.Minute, "how long to wait for the callback")
+	fs.StringVar(&options.Prompt, "prompt", "", "prompt parameter, e.g. consent")
+	fs.StringVar(&options.BotPrompt, "bot-prompt", "", "bot_prompt parameter: normal or aggressive")
+	fs.StringVar(&options.UILocales, "ui-locales", "", "ui_locales parameter")
+	if err := fs.parse(args); err != nil {
+		return err
+	}
+	client, err := fs.client()
+	if err != nil {
+		return err
+	}
+
+	state, err := social.GenerateState()
+	if err != nil {
+		return err
+	}
+	nonce, err := social.GenerateNonce()
+	if err != nil {
+		return err
+	}
+	codeVerifier, err := social.GenerateCodeVerifier(43)
+	if err != nil {
+		return err
+	}
+
+	ln, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return err
+	}
+	redirectURI := fmt.Sprintf("http://%s%s", ln.Addr().String(), callbackPath)
+
+	results := make(chan callbackResult, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc(callbackPath, func(w http.ResponseWriter, r *http.Request) {
+		res := parseCallback(r, state)
+		if res.err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "<p>Login failed: %s</p>", html.EscapeString(res.err.Error()))
+		} else {
+			fmt.Fprint(w, "<p>Login succeeded. You can close this window.</p>")
+		}
+		if errors.Is(res.err, errStateMismatch) {
+			return
+		}
+		select {
+		case results <- res:
+		default:
+		}
+	})
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	options.Nonce = nonce
+	authURL, err := client.GetPKCEWebLoinURL(redirectURI, state, scope, social.PkceChallenge(codeVerifier), options)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(env.stderr, "Waiting for the LINE Login callback on %s\n", redirectURI)
+	fmt.Fprintln(env.stderr, "This callback URL must be registered for the channel.")
+	if noBrowser || env.openBrowser(authURL) != nil {
+		fmt.Fprintf(env.stderr, "Open this URL in a browser:\n\n  %s\n\n", authURL)
+	}
+
+	var res callbackResult
+	select {
+	case res = <-results:
+	case <-time.After(wait):
+		return errors.New("timed out waiting for the callback")
+	}
+	if res.err != nil {
+		return res.err
+	}
+
+	ctx, cancel := fs.context()
+	defer cancel()
+	token, err := client.GetAccessTokenPKCE(redirectURI, res.code, codeVerifier).WithContext(ctx).Do()
+	if err != nil {
+		return err
+	}
+
+	// The code is spent, so the tokens are kept before anything else can fail.
+	creds := newCredentials(fs.channelID, token, time.Now())
+	if err := saveCredentials(fs.credentials, creds); err != nil {
+		if perr := fs.print(creds); perr != nil {
+			return perr
+		}
+		return err
+	}
+	fmt.Fprintf(env.stderr, "Saved credentials to %s\n", fs.credentials)
+	if err := fs.print(creds); err != nil {
+		return err
+	}
+	if token.IDToken != "" {
+		payload, err := token.DecodePayload(fs.channelID)
+		if err != nil {
+			return fmt.Errorf("credentials saved, but the ID token is invalid: %w", err)
+		}
+		if payload.Nonce != nonce {
+			return errors.New("credentials saved, but the ID token nonce does not match the authorize request")
+		}
+	}
+	return nil
+}
+
+// parseCallback validates the state of an authorization callback and
+// extracts the code.
+func parseCallback(r *http.Request, state string) callbackResult {
+	q := r.URL.Query()
+	if q.Get("state") != state {
+		return callbackResult{err: errStateMismatch}
+	}
+	if e := q.Get("error"); e != "" {
+		return callbackResult{err: fmt.Errorf("authorization failed: %s: %s", e, q.Get("error_description"))}
+	}
+	code := q.Get("code")
+	if code == "" {
+		return callbackResult{err: errors.New("missing code")}
+	}
+	return callbackResult{code: code}
+}
+
+// openBrowser opens u with the platform's default browser.
+func openBrowser(u string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", u)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", u)
+	default:
+		cmd = exec.Command("xdg-open", u)
+	}
+	return cmd.Start()
+}
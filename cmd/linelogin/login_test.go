@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+)
+
+// newLoginServer serves the token and profile endpoints. The token response
+// carries idToken when it is not empty.
+func newLoginServer(t *testing.T, idToken string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth2/v2.1/token":
+			r.ParseForm()
+			if r.Form.Get("code") != "auth-code" || r.Form.Get("code_verifier") == "" {
+				http.Error(w, `{"message":"bad request"}`, http.StatusBadRequest)
+				return
+			}
+			fmt.Fprintf(w, `{"access_token":"access-1","refresh_token":"refresh-1","expires_in":3600,"token_type":"Bearer","scope":"profile","id_token":%q}`, idToken)
+		case "/v2/profile":
+			if r.Header.Get("Authorization") != "Bearer access-1" {
+				http.Error(w, `{"message":"unauthorized"}`, http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"userId":"U1234"}`)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// loginEnvironment returns an environment whose browser follows the
+// authorize URL to the callback, after a stale callback with another state.
+func loginEnvironment(t *testing.T, srv *httptest.Server, credsPath string) (*environment, *bytes.Buffer) {
+	t.Helper()
+	env, _, stderr := testEnvironment(t, map[string]string{
+		"LINE_CLIENT_ID":        "1234",
+		"LINE_CLIENT_SECRET":    "secret",
+		"LINE_ENDPOINT_BASE":    srv.URL,
+		"LINE_CREDENTIALS_FILE": credsPath,
+	})
+	env.openBrowser = func(authURL string) error {
+		u, err := url.Parse(authURL)
+		if err != nil {
+			return err
+		}
+		q := u.Query()
+		callback := func(state string) string {
+			return q.Get("redirect_uri") + "?" + url.Values{"code": {"auth-code"}, "state": {state}}.Encode()
+		}
+		go func() {
+			res, err := http.Get(callback("stale"))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			res.Body.Close()
+			if res.StatusCode != http.StatusBadRequest {
+				t.Errorf("stale callback status = %d", res.StatusCode)
+			}
+			if res, err := http.Get(callback(q.Get("state"))); err == nil {
+				res.Body.Close()
+			}
+		}()
+		return nil
+	}
+	return env, stderr
+}
+
+func TestLoginStoresCredentials(t *testing.T) {
+	srv := newLoginServer(t, "")
+	credsPath := filepath.Join(t.TempDir(), "creds", "credentials.json")
+	env, stderr := loginEnvironment(t, srv, credsPath)
+
+	if code := run(env, []string{"login", "--port", "0", "--output", "json"}); code != 0 {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr)
+	}
+	creds, err := loadCredentials(credsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds == nil || creds.AccessToken != "access-1" || creds.RefreshToken != "refresh-1" {
+		t.Fatalf("credentials = %+v", creds)
+	}
+
+	// Other commands pick up the stored access token.
+	if code := run(env, []string{"profile"}); code != 0 {
+		t.Fatalf("profile exit code %d, stderr: %s", code, stderr)
+	}
+}
+
+func TestLoginKeepsCredentialsOfInvalidIDToken(t *testing.T) {
+	srv := newLoginServer(t, "not-a-jwt")
+	credsPath := filepath.Join(t.TempDir(), "credentials.json")
+	env, stderr := loginEnvironment(t, srv, credsPath)
+
+	if code := run(env, []string{"login", "--port", "0"}); code == 0 {
+		t.Fatalf("login with an invalid ID token succeeded, stderr: %s", stderr)
+	}
+	creds, err := loadCredentials(credsPath)
+	if err != nil || creds == nil || creds.AccessToken != "access-1" {
+		t.Errorf("credentials = %+v, %v", creds, err)
+	}
+}
+
+func TestParseCallbackRejectsWrongState(t *testing.T) {
+	r := httptest.NewRequest("GET", "/callback?code=abc&state=other", nil)
+	if res := parseCallback(r, "expected"); res.err == nil {
+		t.Errorf("parseCallback accepted a wrong state")
+	}
+	r = httptest.NewRequest("GET", "/callback?error=access_denied&state=expected", nil)
+	if res := parseCallback(r, "expected"); res.err == nil {
+		t.Errorf("parseCallback accepted an error callback")
+	}
+}
@@ -0,0 +1,96 @@
+// Command linelogin runs LINE Login API operations from the shell.
+//
+// Usage:
+//
+//	linelogin <command> [flags]
+//
+// Credentials are read from flags or from the environment variables
+// LINE_CLIENT_ID, LINE_CLIENT_SECRET, LINE_ACCESS_TOKEN, LINE_REFRESH_TOKEN,
+// LINE_ID_TOKEN, LINE_LOGIN_CODE, LINE_SERVER_URL, LINE_CHANNEL_ACCESS_TOKEN
+// and LINE_ENDPOINT_BASE. Token flags fall back to the credential file
+// written by "linelogin login" (LINE_CREDENTIALS_FILE). Run
+// "linelogin <command> -h" for the flags of a command.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(env *environment, args []string) error
+}
+
+var commands = []command{
+	{"login", "log in through a loopback browser callback and save the tokens", runLogin},
+	{"url", "build an authorize URL with PKCE", runURL},
+	{"exchange", "exchange an authorization code for tokens", runExchange},
+	{"refresh", "refresh an access token", runRefresh},
+	{"verify", "verify an access token", runVerify},
+	{"revoke", "revoke an access token", runRevoke},
+	{"verify-id-token", "verify an ID token with the LINE Platform", runVerifyIDToken},
+	{"inspect-id-token", "decode and check an ID token offline", runInspectIDToken},
+	{"profile", "get the user profile", runProfile},
+	{"userinfo", "get the OIDC userinfo", runUserInfo},
+	{"friendship", "get the friendship status with the linked bot", runFriendship},
+	{"deauthorize", "deauthorize the app for a user", runDeauthorize},
+}
+
+// environment carries the process dependencies so commands can be tested.
+type environment struct {
+	stdout io.Writer
+	stderr io.Writer
+	getenv func(string) string
+
+	openBrowser func(url string) error
+}
+
+func main() {
+	env := &environment{
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+		getenv: os.Getenv,
+
+		openBrowser: openBrowser,
+	}
+	os.Exit(run(env, os.Args[1:]))
+}
+
+func run(env *environment, args []string) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		usage(env.stderr)
+		return 2
+	}
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		if err := cmd.run(env, args[1:]); err != nil {
+			if err == errUsage {
+				return 2
+			}
+			fmt.Fprintf(env.stderr, "linelogin %s: %v\n", cmd.name, err)
+			return 1
+		}
+		return 0
+	}
+	fmt.Fprintf(env.stderr, "linelogin: unknown command %q\n\n", args[0])
+	usage(env.stderr)
+	return 2
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: linelogin <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	sorted := make([]command, len(commands))
+	copy(sorted, commands)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].name < sorted[j].name })
+	for _, cmd := range sorted {
+		fmt.Fprintf(w, "  %-16s %s\n", cmd.name, cmd.usage)
+	}
+}
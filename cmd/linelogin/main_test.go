@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testEnvironment(t *testing.T, vars map[string]string) (*environment, *bytes.Buffer, *bytes.Buffer) {
+	if vars == nil {
+		vars = map[string]string{}
+	}
+	if _, ok := vars["LINE_CREDENTIALS_FILE"]; !ok {
+		vars["LINE_CREDENTIALS_FILE"] = filepath.Join(t.TempDir(), "credentials.json")
+	}
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	return &environment{
+		stdout: stdout,
+		stderr: stderr,
+		getenv: func(key string) string { return vars[key] },
+		openBrowser: func(string) error {
+			return errors.New("no browser in tests")
+		},
+	}, stdout, stderr
+}
+
+func TestProfileAgainstEndpointBase(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/profile" || r.Header.Get("Authorization") != "Bearer token-1" {
+			http.Error(w, `{"message":"unexpected"}`, http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"userId":"U1234","displayName":"Brown"}`)
+	}))
+	defer srv.Close()
+
+	env, stdout, stderr := testEnvironment(t, map[string]string{
+		"LINE_CLIENT_ID":     "1234",
+		"LINE_CLIENT_SECRET": "secret",
+		"LINE_ACCESS_TOKEN":  "token-1",
+	})
+	if code := run(env, []string{"profile", "--endpoint-base", srv.URL, "--output", "json"}); code != 0 {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["userId"] != "U1234" {
+		t.Errorf("userId = %v", got["userId"])
+	}
+
+	stdout.Reset()
+	if code := run(env, []string{"profile", "--endpoint-base", srv.URL}); code != 0 {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr)
+	}
+	if !strings.Contains(stdout.String(), "userId         U1234") {
+		t.Errorf("table output = %q", stdout)
+	}
+}
+
+func TestTableOutputNumbers(t *testing.T) {
+	var buf bytes.Buffer
+	err := printResult(&buf, "table", map[string]any{"expires_in": 2592000, "exp": 1700000000, "ratio": 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"expires_in  2592000", "exp         1700000000", "ratio       0.5"} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("table output = %q, want line %q", buf.String(), line)
+		}
+	}
+}
+
+func TestURLCommand(t *testing.T) {
+	env, stdout, stderr := testEnvironment(t, map[string]string{
+		"LINE_CLIENT_ID":     "1234",
+		"LINE_CLIENT_SECRET": "secret",
+	})
+	if code := run(env, []string{"url", "--redirect-uri", "https://example.com/cb", "--output", "json"}); code != 0 {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr)
+	}
+	var res urlResult
+	if err := json.Unmarshal(stdout.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(res.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("state") != res.State || q.Get("nonce") != res.Nonce || q.Get("code_challenge_method") != "S256" {
+		t.Errorf("url query = %v", q)
+	}
+}
+
+func TestMissingCredentials(t *testing.T) {
+	env, _, stderr := testEnvironment(t, nil)
+	if code := run(env, []string{"verify", "--access-token", "x"}); code != 1 {
+		t.Fatalf("exit code %d, want 1", code)
+	}
+	if !strings.Contains(stderr.String(), "LINE_CLIENT_ID") {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
@@ -0,0 +1,403 @@
+package social
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Deauthorize result classes
+const (
+	DeauthorizeOK             = "ok"
+	DeauthorizeAlreadyRevoked = "already_revoked"
+	DeauthorizeRejected       = "rejected"
+	DeauthorizeUnauthorized   = "unauthorized"
+	DeauthorizeRateLimited    = "rate_limited"
+	DeauthorizeServerError    = "server_error"
+	DeauthorizeNetworkError   = "network_error"
+	DeauthorizeCanceled       = "canceled"
+)
+
+// DeauthorizeItem is one user of a batch.
+type DeauthorizeItem struct {
+	// UserID identifies the user in reports and the checkpoint. When empty,
+	// a hash of the access token is used.
+	UserID      string
+	AccessToken string
+}
+
+func (item DeauthorizeItem) key() string {
+	if item.UserID != "" {
+		return item.UserID
+	}
+	sum := sha256.Sum256([]byte(item.AccessToken))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// DeauthorizeResult is the outcome for one user. Access tokens are never
+// included.
+type DeauthorizeResult struct {
+	Key        string        `json:"key"`
+	Class      string        `json:"class"`
+	StatusCode int           `json:"status_code,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Attempts   int           `json:"attempts"`
+	Duration   time.Duration `json:"duration"`
+	Time       time.Time     `json:"time"`
+}
+
+// Succeeded reports whether the user is deauthorized. Tokens that were
+// already revoked count as success.
+func (r DeauthorizeResult) Succeeded() bool {
+	return r.Class == DeauthorizeOK || r.Class == DeauthorizeAlreadyRevoked
+}
+
+// final reports whether a resumed run should skip the user.
+func (r DeauthorizeResult) final() bool {
+	return r.Succeeded() || r.Class == DeauthorizeRejected
+}
+
+// DeauthorizeSummary counts the results of a batch.
+type DeauthorizeSummary struct {
+	Total     int
+	Succeeded int
+	Failed    int
+	// Skipped users were completed by an earlier run of the same checkpoint.
+	Skipped int
+	ByClass map[string]int
+}
+
+// ResultWriter receives the result of each user of a batch.
+type ResultWriter interface {
+	WriteResult(DeauthorizeResult) error
+	Flush() error
+}
+
+// DeauthorizeBatch deauthorizes many users with bounded concurrency and
+// rate, retrying rate-limited and failed requests. With a checkpoint file a
+// stopped batch can be resumed; users already done are skipped.
+type DeauthorizeBatch struct {
+	Client             *Client
+	ChannelAccessToken string
+
+	// Concurrency is the number of parallel requests. Default 4.
+	Concurrency int
+	// RatePerSecond limits the request rate. Default 10; negative for no limit.
+	RatePerSecond float64
+	// Retry applies to rate-limited, 5xx and network failures. Default 3 attempts.
+	Retry RetryPolicy
+	// CheckpointFile records finished users as JSON Lines.
+	CheckpointFile string
+	// Reports receive every result, e.g. NewCSVResultWriter(f).
+	Reports []ResultWriter
+}
+
+// Run deauthorizes the users of items. It stops early when the channel
+// access token is rejected, since no later request can succeed.
+func (b *DeauthorizeBatch) Run(ctx context.Context, items iter.Seq[DeauthorizeItem]) (*DeauthorizeSummary, error) {
+	summary := &DeauthorizeSummary{ByClass: map[string]int{}}
+	done, err := loadCheckpoint(b.CheckpointFile)
+	if err != nil {
+		return summary, err
+	}
+	var checkpoint *os.File
+	if b.CheckpointFile != "" {
+		checkpoint, err = os.OpenFile(b.CheckpointFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			return summary, err
+		}
+		defer checkpoint.Close()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	concurrency := b.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	limiter := newRateLimiter(b.RatePerSecond)
+	retry := b.Retry
+	if retry.MaxAttempts == 0 {
+		retry.MaxAttempts = 3
+	}
+
+	jobs := make(chan DeauthorizeItem)
+	results := make(chan DeauthorizeResult)
+	var workers sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for item := range jobs {
+				results <- b.deauthorize(ctx, item, limiter, retry)
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for item := range items {
+			key := item.key()
+			if done[key] {
+				summary.Skipped++
+				continue
+			}
+			select {
+			case jobs <- item:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		workers.Wait()
+		close(results)
+	}()
+
+	var runErr error
+	for res := range results {
+		summary.Total++
+		summary.ByClass[res.Class]++
+		if res.Succeeded() {
+			summary.Succeeded++
+		} else {
+			summary.Failed++
+		}
+		if res.Class == DeauthorizeUnauthorized && runErr == nil {
+			runErr = fmt.Errorf("channel access token rejected: %s", res.Error)
+			cancel()
+		}
+		if checkpoint != nil {
+			if err := writeJSONLine(checkpoint, res); err != nil && runErr == nil {
+				runErr = err
+				cancel()
+			}
+		}
+		for _, w := range b.Reports {
+			if err := w.WriteResult(res); err != nil && runErr == nil {
+				runErr = err
+				cancel()
+			}
+		}
+	}
+	for _, w := range b.Reports {
+		if err := w.Flush(); err != nil && runErr == nil {
+			runErr = err
+		}
+	}
+	if checkpoint != nil {
+		if err := checkpoint.Sync(); err != nil && runErr == nil {
+			runErr = err
+		}
+	}
+	if runErr == nil {
+		runErr = ctx.Err()
+	}
+	return summary, runErr
+}
+
+func (b *DeauthorizeBatch) deauthorize(ctx context.Context, item DeauthorizeItem, limiter *rateLimiter, retry RetryPolicy) DeauthorizeResult {
+	res := DeauthorizeResult{Key: item.key()}
+	start := time.Now()
+	for {
+		if err := limiter.wait(ctx); err != nil {
+			res.Class, res.Error = DeauthorizeCanceled, err.Error()
+			break
+		}
+		res.Attempts++
+		// The batch retries on its own, so the client's RetryPolicy must not
+		// multiply the attempts.
+		_, err := b.Client.Deauthorize(b.ChannelAccessToken, item.AccessToken).WithContext(withoutRetry(ctx)).Do()
+		res.Class, res.StatusCode = classifyDeauthorize(ctx, err)
+		res.Error = ""
+		if err != nil {
+			res.Error = err.Error()
+		}
+		transient := res.Class == DeauthorizeRateLimited || res.Class == DeauthorizeServerError || res.Class == DeauthorizeNetworkError
+		if !transient || res.Attempts >= retry.attempts() {
+			break
+		}
+		if err := sleepContext(ctx, retry.delay(res.Attempts, nil)); err != nil {
+			res.Class, res.Error = DeauthorizeCanceled, err.Error()
+			break
+		}
+	}
+	res.Duration = time.Since(start)
+	res.Time = time.Now()
+	return res
+}
+
+// classifyDeauthorize maps a Deauthorize error to a result class. A 400
+// saying the user access token is invalid means it was already revoked or
+// has expired; other 400s are failures.
+func classifyDeauthorize(ctx context.Context, err error) (string, int) {
+	if err == nil {
+		return DeauthorizeOK, http.StatusNoContent
+	}
+	if ctx.Err() != nil {
+		return DeauthorizeCanceled, 0
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return DeauthorizeNetworkError, 0
+	}
+	switch code := apiErr.Code; {
+	case code == http.StatusBadRequest && invalidUserToken(apiErr.Response):
+		return DeauthorizeAlreadyRevoked, code
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return DeauthorizeUnauthorized, code
+	case code == http.StatusTooManyRequests:
+		return DeauthorizeRateLimited, code
+	case code >= 500:
+		return DeauthorizeServerError, code
+	default:
+		return DeauthorizeRejected, code
+	}
+}
+
+// invalidUserToken reports whether an error response says the user access
+// token is invalid, revoked or expired.
+func invalidUserToken(res *ErrorResponse) bool {
+	if res == nil {
+		return false
+	}
+	if res.Error == "invalid_token" {
+		return true
+	}
+	text := strings.ToLower(res.Message + " " + res.ErrorDescription)
+	if !strings.Contains(text, "token") {
+		return false
+	}
+	return strings.Contains(text, "invalid") || strings.Contains(text, "revoked") || strings.Contains(text, "expired")
+}
+
+// loadCheckpoint returns the keys a previous run finished.
+func loadCheckpoint(path string) (map[string]bool, error) {
+	done := map[string]bool{}
+	if path == "" {
+		return done, nil
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var res DeauthorizeResult
+		if err := json.Unmarshal(scanner.Bytes(), &res); err != nil {
+			// A line cut off by a crash; the user is simply retried.
+			continue
+		}
+		// The last result for a key wins.
+		done[res.Key] = res.final()
+	}
+	return done, scanner.Err()
+}
+
+func writeJSONLine(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// rateLimiter spaces requests evenly.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newRateLimiter(perSecond float64) *rateLimiter {
+	if perSecond == 0 {
+		perSecond = 10
+	}
+	if perSecond < 0 {
+		return &rateLimiter{}
+	}
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l.interval == 0 {
+		return ctx.Err()
+	}
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+	return sleepContext(ctx, at.Sub(now))
+}
+
+// NewCSVResultWriter returns a ResultWriter writing CSV with a header row.
+func NewCSVResultWriter(w io.Writer) ResultWriter {
+	return &csvResultWriter{w: csv.NewWriter(w)}
+}
+
+type csvResultWriter struct {
+	w      *csv.Writer
+	header bool
+}
+
+func (c *csvResultWriter) WriteResult(r DeauthorizeResult) error {
+	if !c.header {
+		c.header = true
+		if err := c.w.Write([]string{"key", "class", "succeeded", "status_code", "attempts", "duration_ms", "time", "error"}); err != nil {
+			return err
+		}
+	}
+	return c.w.Write([]string{
+		r.Key,
+		r.Class,
+		strconv.FormatBool(r.Succeeded()),
+		strconv.Itoa(r.StatusCode),
+		strconv.Itoa(r.Attempts),
+		strconv.FormatInt(r.Duration.Milliseconds(), 10),
+		r.Time.UTC().Format(time.RFC3339),
+		r.Error,
+	})
+}
+
+func (c *csvResultWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// NewJSONLResultWriter returns a ResultWriter writing one JSON object per line.
+func NewJSONLResultWriter(w io.Writer) ResultWriter {
+	return &jsonlResultWriter{w: bufio.NewWriter(w)}
+}
+
+type jsonlResultWriter struct {
+	w *bufio.Writer
+}
+
+func (j *jsonlResultWriter) WriteResult(r DeauthorizeResult) error {
+	return writeJSONLine(j.w, r)
+}
+
+func (j *jsonlResultWriter) Flush() error {
+	return j.w.Flush()
+}
@@ -0,0 +1,108 @@
+package social
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDeauthorizeBatch(t *testing.T) {
+	var mu sync.Mutex
+	calls := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		token := r.Form.Get("userAccessToken")
+		mu.Lock()
+		calls[token]++
+		n := calls[token]
+		mu.Unlock()
+		switch {
+		case r.Header.Get("Authorization") != "Bearer channel-token":
+			w.WriteHeader(http.StatusUnauthorized)
+		case token == "revoked":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_request","error_description":"invalid token"}`))
+		case token == "malformed":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message":"Invalid request","error":"invalid_request","error_description":"missing parameter"}`))
+		case token == "flaky" && n == 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case token == "down":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+	// The batch retries on its own; the client policy must not add attempts.
+	client, err := New("1234", "secret", WithEndpointBase(srv.URL), WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items := []DeauthorizeItem{
+		{UserID: "U1", AccessToken: "ok-1"},
+		{UserID: "U2", AccessToken: "revoked"},
+		{UserID: "U3", AccessToken: "flaky"},
+		{UserID: "U4", AccessToken: "down"},
+		{UserID: "U5", AccessToken: "malformed"},
+		{AccessToken: "ok-2"},
+	}
+	checkpoint := filepath.Join(t.TempDir(), "checkpoint.jsonl")
+	var csvOut, jsonOut bytes.Buffer
+	batch := &DeauthorizeBatch{
+		Client:             client,
+		ChannelAccessToken: "channel-token",
+		Concurrency:        2,
+		RatePerSecond:      -1,
+		Retry:              RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond},
+		CheckpointFile:     checkpoint,
+		Reports:            []ResultWriter{NewCSVResultWriter(&csvOut), NewJSONLResultWriter(&jsonOut)},
+	}
+	summary, err := batch.Run(AllowRetry(context.Background()), slices.Values(items))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Total != 6 || summary.Succeeded != 4 || summary.Failed != 2 || summary.ByClass[DeauthorizeAlreadyRevoked] != 1 ||
+		summary.ByClass[DeauthorizeServerError] != 1 || summary.ByClass[DeauthorizeRejected] != 1 {
+		t.Errorf("summary = %+v", summary)
+	}
+	if calls["flaky"] != 2 || calls["down"] != 2 {
+		t.Errorf("calls = %v", calls)
+	}
+	if lines := strings.Count(csvOut.String(), "\n"); lines != 7 {
+		t.Errorf("CSV has %d lines, want header and 6 rows:\n%s", lines, csvOut.String())
+	}
+	if strings.Contains(csvOut.String()+jsonOut.String(), "ok-2") {
+		t.Errorf("report contains an access token")
+	}
+	if !strings.Contains(jsonOut.String(), `"key":"U4","class":"server_error"`) ||
+		!strings.Contains(jsonOut.String(), `APIError 400 Invalid request: invalid_request: missing parameter`) {
+		t.Errorf("JSONL report = %s", jsonOut.String())
+	}
+
+	// A resumed run only retries the user that failed transiently.
+	batch.Reports = nil
+	summary, err = batch.Run(context.Background(), slices.Values(items))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Skipped != 5 || summary.Total != 1 || calls["down"] != 4 || calls["ok-1"] != 1 {
+		t.Errorf("resumed summary = %+v, calls = %v", summary, calls)
+	}
+
+	// A rejected channel access token stops the batch.
+	batch.CheckpointFile = ""
+	batch.ChannelAccessToken = "wrong"
+	batch.Concurrency = 1
+	if _, err := batch.Run(context.Background(), slices.Values(items)); err == nil || !strings.Contains(err.Error(), "channel access token rejected") {
+		t.Errorf("Run with a wrong channel token = %v", err)
+	}
+}
@@ -4,17 +4,44 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"net/http"
 )
 
 // errors
 var (
-	ErrInvalidSignature = errors.New("invalid signature")
+	ErrInvalidSignature      = errors.New("invalid signature")
+	ErrRedirectURINotAllowed = errors.New("redirect URI not allowed")
+	ErrInvalidReturnTo       = errors.New("invalid return_to")
+	ErrReturnToExpired       = errors.New("return_to expired")
+	ErrStateNotFound         = errors.New("login state not found or expired")
+	ErrStateBindingMismatch  = errors.New("login state was started by another browser")
+	ErrReplayDetected        = errors.New("replay detected")
+	ErrUnknownChannel        = errors.New("unknown channel")
+	ErrMissingToken          = errors.New("missing bearer token")
+	ErrInvalidToken          = errors.New("invalid or expired access token")
+	ErrClientNotAllowed      = errors.New("token issued for a channel that is not allowed")
+	ErrMissingScope          = errors.New("missing required scope")
+	ErrTokenExpiring         = errors.New("token expires too soon")
+	ErrUnknownUser           = errors.New("token does not identify the user")
+	ErrIDTokenUserMismatch   = errors.New("ID token is for another user")
+	ErrStepUpRequired        = errors.New("stronger or more recent authentication required")
+	ErrImageHostNotAllowed   = errors.New("image host not allowed")
+	ErrImageTooLarge         = errors.New("image too large")
+	ErrUnsupportedImageType  = errors.New("unsupported image type")
+
+	ErrAccountLinkNonceNotFound = errors.New("account link nonce not found or expired")
+	ErrAccountLinkFailed        = errors.New("account link failed")
+	ErrAccountLinkUserMismatch  = errors.New("account link event is for another LINE user")
+
+	ErrReauthenticationRequired = errors.New("refresh token expired, the user must log in again")
 )
 
 // APIError type
 type APIError struct {
 	Code     int
 	Response *ErrorResponse
+	// Header holds the response headers, e.g. Retry-After of a 429.
+	Header http.Header
 }
 
 // Error method
@@ -23,6 +50,12 @@ func (e *APIError) Error() string {
 	fmt.Fprintf(&buf, "Social SDK: APIError %d ", e.Code)
 	if e.Response != nil {
 		fmt.Fprintf(&buf, "%s", e.Response.Message)
+		if e.Response.Error != "" {
+			if e.Response.Message != "" {
+				buf.WriteString(": ")
+			}
+			fmt.Fprintf(&buf, "%s: %s", e.Response.Error, e.Response.ErrorDescription)
+		}
 		for _, d := range e.Response.Details {
 			fmt.Fprintf(&buf, "\n[%s] %s", d.Property, d.Message)
 		}
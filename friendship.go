@@ -0,0 +1,166 @@
+package social
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// BotPrompt modes for AuthRequestOptions.BotPrompt
+const (
+	BotPromptNormal     = "normal"
+	BotPromptAggressive = "aggressive"
+)
+
+// Friendship sources
+const (
+	FriendshipSourceCallback = "callback"
+	FriendshipSourceAPI      = "api"
+	FriendshipSourceWebhook  = "webhook"
+)
+
+// FriendshipChange is reported when a user's friend flag changes.
+type FriendshipChange struct {
+	UserID string
+	Friend bool
+	// Previous is the earlier flag; it is meaningless when Known is false.
+	Previous bool
+	Known    bool
+	Source   string
+	Time     time.Time
+}
+
+// BotPromptStats counts the logins of one bot_prompt mode.
+type BotPromptStats struct {
+	Logins int
+	// Converted logins added the bot as a friend (friendship_status_changed=true).
+	Converted int
+}
+
+// ConversionRate returns Converted / Logins.
+func (s BotPromptStats) ConversionRate() float64 {
+	if s.Logins == 0 {
+		return 0
+	}
+	return float64(s.Converted) / float64(s.Logins)
+}
+
+// FriendshipTracker records whether users are friends of the bot, from login
+// callbacks, GetFriendshipStatus calls and follow/unfollow webhook events.
+type FriendshipTracker struct {
+	onChange func(FriendshipChange)
+	now      func() time.Time
+
+	mu      sync.Mutex
+	friends map[string]bool
+	stats   map[string]*BotPromptStats
+}
+
+// NewFriendshipTracker returns an empty tracker. onChange, when not nil, is
+// called for every change of a friend flag.
+func NewFriendshipTracker(onChange func(FriendshipChange)) *FriendshipTracker {
+	return &FriendshipTracker{
+		onChange: onChange,
+		now:      time.Now,
+		friends:  map[string]bool{},
+		stats:    map[string]*BotPromptStats{},
+	}
+}
+
+// Friend returns the last known friend flag of a user.
+func (t *FriendshipTracker) Friend(userID string) (friend, known bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	friend, known = t.friends[userID]
+	return friend, known
+}
+
+// Set records the friend flag of a user.
+func (t *FriendshipTracker) Set(userID string, friend bool, source string) {
+	t.mu.Lock()
+	previous, known := t.friends[userID]
+	t.friends[userID] = friend
+	t.mu.Unlock()
+	if t.onChange != nil && (!known || previous != friend) {
+		t.onChange(FriendshipChange{
+			UserID:   userID,
+			Friend:   friend,
+			Previous: previous,
+			Known:    known,
+			Source:   source,
+			Time:     t.now(),
+		})
+	}
+}
+
+// RecordCallback records a login callback of a request with the given
+// bot_prompt mode. changed is its friendship_status_changed parameter; when
+// true the user has just added the bot.
+func (t *FriendshipTracker) RecordCallback(userID, botPrompt string, changed bool) {
+	t.mu.Lock()
+	s, ok := t.stats[botPrompt]
+	if !ok {
+		s = &BotPromptStats{}
+		t.stats[botPrompt] = s
+	}
+	s.Logins++
+	if changed {
+		s.Converted++
+	}
+	t.mu.Unlock()
+	if changed {
+		t.Set(userID, true, FriendshipSourceCallback)
+	}
+}
+
+// RecordLogin records the result of CompleteLogin. Logins without
+// bot_prompt or without an ID token are ignored.
+func (t *FriendshipTracker) RecordLogin(result *LoginResult) error {
+	if result.FriendshipStatusChanged == nil || result.State == nil || result.State.BotPrompt == "" {
+		return nil
+	}
+	if result.Payload == nil {
+		return errors.New("login result has no ID token to identify the user")
+	}
+	t.RecordCallback(result.Payload.Sub, result.State.BotPrompt, *result.FriendshipStatusChanged)
+	return nil
+}
+
+// Refresh asks the LINE Platform whether the user is a friend and records
+// the answer.
+func (t *FriendshipTracker) Refresh(ctx context.Context, client *Client, userID, accessToken string) (bool, error) {
+	res, err := client.GetFriendshipStatus(accessToken).WithContext(ctx).Do()
+	if err != nil {
+		return false, err
+	}
+	t.Set(userID, res.FriendFlag, FriendshipSourceAPI)
+	return res.FriendFlag, nil
+}
+
+// HandleFollow records a follow event. Register it with WebhookHandler.OnFollow.
+func (t *FriendshipTracker) HandleFollow(ctx context.Context, e *FollowEvent) error {
+	if e.Source.UserID != "" {
+		t.Set(e.Source.UserID, true, FriendshipSourceWebhook)
+	}
+	return nil
+}
+
+// HandleUnfollow records an unfollow event. Register it with WebhookHandler.OnUnfollow.
+func (t *FriendshipTracker) HandleUnfollow(ctx context.Context, e *UnfollowEvent) error {
+	if e.Source.UserID != "" {
+		t.Set(e.Source.UserID, false, FriendshipSourceWebhook)
+	}
+	return nil
+}
+
+// Stats returns the login counts per bot_prompt mode.
+func (t *FriendshipTracker) Stats() map[string]BotPromptStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	stats := make(map[string]BotPromptStats, len(t.stats))
+	for mode, s := range t.stats {
+		stats[mode] = *s
+	}
+	return stats
+}
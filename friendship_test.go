@@ -0,0 +1,64 @@
+package social
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFriendshipTracker(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"friendFlag":false}`)
+	}))
+	defer srv.Close()
+	client, err := New("1234", "secret", WithEndpointBase(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var changes []string
+	tracker := NewFriendshipTracker(func(c FriendshipChange) {
+		changes = append(changes, fmt.Sprintf("%s:%v:%s", c.UserID, c.Friend, c.Source))
+	})
+	tracker.RecordCallback("U1", BotPromptAggressive, true)
+	tracker.RecordCallback("U2", BotPromptAggressive, false)
+	tracker.RecordCallback("U3", BotPromptNormal, false)
+	changed := true
+	err = tracker.RecordLogin(&LoginResult{
+		State:                   &LoginState{BotPrompt: BotPromptNormal},
+		Payload:                 &LineProfilePlusPayload{BasicPayload: BasicPayload{Sub: "U4"}},
+		FriendshipStatusChanged: &changed,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A repeated flag is not a change.
+	tracker.HandleFollow(context.Background(), &FollowEvent{WebhookEvent: WebhookEvent{Source: EventSource{UserID: "U1"}}})
+	tracker.HandleUnfollow(context.Background(), &UnfollowEvent{WebhookEvent: WebhookEvent{Source: EventSource{UserID: "U1"}}})
+	if friend, err := tracker.Refresh(context.Background(), client, "U2", "token"); err != nil || friend {
+		t.Errorf("Refresh = %v, %v", friend, err)
+	}
+
+	want := "U1:true:callback U4:true:callback U1:false:webhook U2:false:api"
+	if got := strings.Join(changes, " "); got != want {
+		t.Errorf("changes = %s, want %s", got, want)
+	}
+	if friend, known := tracker.Friend("U4"); !friend || !known {
+		t.Errorf("Friend(U4) = %v, %v", friend, known)
+	}
+	if _, known := tracker.Friend("U3"); known {
+		t.Errorf("Friend(U3) is known")
+	}
+
+	stats := tracker.Stats()
+	if s := stats[BotPromptAggressive]; s.Logins != 2 || s.Converted != 1 || s.ConversionRate() != 0.5 {
+		t.Errorf("aggressive stats = %+v", s)
+	}
+	if s := stats[BotPromptNormal]; s.Logins != 2 || s.Converted != 1 {
+		t.Errorf("normal stats = %+v", s)
+	}
+}
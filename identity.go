@@ -0,0 +1,184 @@
+package social
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Identity sources
+const (
+	SourceProfile    = "profile"
+	SourceUserInfo   = "userinfo"
+	SourceFriendship = "friendship"
+	SourceIDToken    = "id_token"
+)
+
+// Identity fields
+const (
+	FieldUserID        = "userId"
+	FieldDisplayName   = "displayName"
+	FieldPictureURL    = "pictureUrl"
+	FieldStatusMessage = "statusMessage"
+	FieldEmail         = "email"
+	FieldFriendFlag    = "friendFlag"
+)
+
+// Identity is everything a login token tells about the user, merged from
+// the profile, userinfo and friendship endpoints and the ID token.
+type Identity struct {
+	UserID        string
+	DisplayName   string
+	PictureURL    string
+	StatusMessage string
+	Email         string
+	// FriendFlag is nil when the friendship status is unavailable.
+	FriendFlag *bool
+
+	// Sources maps each filled field to the source it was taken from.
+	Sources map[string]string
+	// Unavailable maps each field that could not be filled to the reason,
+	// e.g. `missing scope "email"`.
+	Unavailable map[string]string
+	// Errors holds the failures of individual sources.
+	Errors map[string]error
+}
+
+// FetchIdentity calls the endpoints the token's scopes allow concurrently
+// and merges the results. The ID token is validated offline with the channel
+// secret, and only a valid ID token for the user the APIs returned
+// contributes fields; one for another user fails with ErrIDTokenUserMismatch.
+// A failing source is recorded in Errors; FetchIdentity only fails when no
+// source could tell the user ID.
+func (client *Client) FetchIdentity(ctx context.Context, token *TokenResponse) (*Identity, error) {
+	scopes := token.Scopes()
+	hasProfile := scopes.Has(ScopeProfile)
+	hasOpenID := scopes.Has(ScopeOpenID)
+
+	var (
+		wg         sync.WaitGroup
+		profile    *GetUserProfileResponse
+		userInfo   *GetUserInfoResponse
+		friendship *GetFriendshipStatusResponse
+		errs       [3]error
+	)
+	if hasProfile {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			profile, errs[0] = client.GetUserProfile(token.AccessToken).WithContext(ctx).Do()
+		}()
+		go func() {
+			defer wg.Done()
+			friendship, errs[2] = client.GetFriendshipStatus(token.AccessToken).WithContext(ctx).Do()
+		}()
+	}
+	if hasOpenID {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			userInfo, errs[1] = client.GetUserInfo(token.AccessToken).WithContext(ctx).Do()
+		}()
+	}
+
+	id := &Identity{
+		Sources:     map[string]string{},
+		Unavailable: map[string]string{},
+		Errors:      map[string]error{},
+	}
+	var payload *LineProfilePlusPayload
+	if token.IDToken != "" {
+		var err error
+		if payload, err = client.validateIDToken(token.IDToken, "", nil); err != nil {
+			id.Errors[SourceIDToken] = err
+		}
+	}
+	wg.Wait()
+	for i, source := range []string{SourceProfile, SourceUserInfo, SourceFriendship} {
+		if errs[i] != nil {
+			id.Errors[source] = errs[i]
+		}
+	}
+
+	if profile != nil {
+		id.set(FieldUserID, &id.UserID, profile.UserID, SourceProfile)
+		id.set(FieldDisplayName, &id.DisplayName, profile.DisplayName, SourceProfile)
+		id.set(FieldPictureURL, &id.PictureURL, profile.PictureURL, SourceProfile)
+		id.set(FieldStatusMessage, &id.StatusMessage, profile.StatusMessage, SourceProfile)
+	}
+	if userInfo != nil {
+		id.set(FieldUserID, &id.UserID, userInfo.Sub, SourceUserInfo)
+		id.set(FieldDisplayName, &id.DisplayName, userInfo.Name, SourceUserInfo)
+		id.set(FieldPictureURL, &id.PictureURL, userInfo.Picture, SourceUserInfo)
+	}
+	// A stale stored ID token must not attach another user's email.
+	if payload != nil && id.UserID != "" && payload.Sub != id.UserID {
+		id.Errors[SourceIDToken] = fmt.Errorf("%w: sub %s, user %s", ErrIDTokenUserMismatch, payload.Sub, id.UserID)
+		payload = nil
+	}
+	if payload != nil {
+		id.set(FieldUserID, &id.UserID, payload.Sub, SourceIDToken)
+		id.set(FieldDisplayName, &id.DisplayName, payload.Name, SourceIDToken)
+		id.set(FieldPictureURL, &id.PictureURL, payload.Picture, SourceIDToken)
+		id.set(FieldEmail, &id.Email, payload.Email, SourceIDToken)
+	}
+	if friendship != nil {
+		flag := friendship.FriendFlag
+		id.FriendFlag = &flag
+		id.Sources[FieldFriendFlag] = SourceFriendship
+	}
+
+	id.explain(scopes, hasProfile, hasOpenID, token.IDToken != "")
+	if id.UserID == "" {
+		var all []error
+		for _, err := range id.Errors {
+			all = append(all, err)
+		}
+		return id, fmt.Errorf("no source returned the user ID: %w", errors.Join(all...))
+	}
+	return id, nil
+}
+
+// set fills an empty field from source.
+func (id *Identity) set(field string, dst *string, value, source string) {
+	if *dst != "" || value == "" {
+		return
+	}
+	*dst = value
+	id.Sources[field] = source
+}
+
+// explain records why the fields that are still empty are unavailable.
+func (id *Identity) explain(scopes ScopeSet, hasProfile, hasOpenID, hasIDToken bool) {
+	reason := func(field string, scope string, sources ...string) {
+		if _, ok := id.Sources[field]; ok {
+			return
+		}
+		if !scopes.Has(scope) {
+			id.Unavailable[field] = fmt.Sprintf("missing scope %q", scope)
+			return
+		}
+		for _, source := range sources {
+			if err, ok := id.Errors[source]; ok {
+				id.Unavailable[field] = fmt.Sprintf("%s failed: %v", source, err)
+				return
+			}
+		}
+		id.Unavailable[field] = "not provided"
+	}
+	identityScope := ScopeProfile
+	if !hasProfile && hasOpenID {
+		identityScope = ScopeOpenID
+	}
+	reason(FieldUserID, identityScope, SourceProfile, SourceUserInfo, SourceIDToken)
+	reason(FieldDisplayName, ScopeProfile, SourceProfile, SourceUserInfo)
+	reason(FieldPictureURL, ScopeProfile, SourceProfile, SourceUserInfo)
+	reason(FieldStatusMessage, ScopeProfile, SourceProfile)
+	reason(FieldFriendFlag, ScopeProfile, SourceFriendship)
+	if _, ok := id.Sources[FieldEmail]; !ok && scopes.Has(ScopeEmail) && !hasIDToken {
+		id.Unavailable[FieldEmail] = "no ID token"
+		return
+	}
+	reason(FieldEmail, ScopeEmail, SourceIDToken)
+}
@@ -0,0 +1,100 @@
+package social
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFetchIdentity(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case APIEndpointGetUserProfile:
+			fmt.Fprint(w, `{"userId":"U1234","displayName":"Brown","statusMessage":"hello"}`)
+		case APIEndpointUserInfo:
+			fmt.Fprint(w, `{"sub":"U1234","name":"Brown (userinfo)","picture":"https://profile.line-scdn.net/abc"}`)
+		case APIEndpointGetFriendshipStratus:
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+	client, err := New("1234", "secret", WithEndpointBase(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := client.FetchIdentity(context.Background(), &TokenResponse{
+		AccessToken: "token",
+		Scope:       "profile openid",
+		IDToken:     testIDToken(t, "secret", testClaims(time.Now())),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.UserID != "U1234" || id.DisplayName != "Brown" || id.StatusMessage != "hello" {
+		t.Errorf("identity = %+v", id)
+	}
+	if id.Sources[FieldDisplayName] != SourceProfile || id.Sources[FieldPictureURL] != SourceUserInfo {
+		t.Errorf("Sources = %v", id.Sources)
+	}
+	// The ID token carries an email, but the email scope was not granted.
+	if id.Email != "brown@example.com" || id.Sources[FieldEmail] != SourceIDToken {
+		t.Errorf("Email = %q from %q", id.Email, id.Sources[FieldEmail])
+	}
+	if id.FriendFlag != nil || id.Errors[SourceFriendship] == nil {
+		t.Errorf("FriendFlag = %v, Errors = %v", id.FriendFlag, id.Errors)
+	}
+	if !strings.HasPrefix(id.Unavailable[FieldFriendFlag], "friendship failed") {
+		t.Errorf("Unavailable = %v", id.Unavailable)
+	}
+
+	id, err = client.FetchIdentity(context.Background(), &TokenResponse{AccessToken: "token", Scope: "openid email"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.Sources[FieldUserID] != SourceUserInfo {
+		t.Errorf("Sources = %v", id.Sources)
+	}
+	if id.Unavailable[FieldStatusMessage] != `missing scope "profile"` || id.Unavailable[FieldEmail] != "no ID token" {
+		t.Errorf("Unavailable = %v", id.Unavailable)
+	}
+
+	// A forged ID token contributes nothing.
+	id, err = client.FetchIdentity(context.Background(), &TokenResponse{
+		AccessToken: "token",
+		Scope:       "openid email",
+		IDToken:     testIDToken(t, "forged", testClaims(time.Now())),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.Email != "" || id.Errors[SourceIDToken] == nil || id.Sources[FieldUserID] != SourceUserInfo {
+		t.Errorf("identity with forged ID token = %+v", id)
+	}
+
+	// An ID token of another user contributes nothing either.
+	claims := testClaims(time.Now())
+	claims["sub"] = "U9999"
+	id, err = client.FetchIdentity(context.Background(), &TokenResponse{
+		AccessToken: "token",
+		Scope:       "profile openid email",
+		IDToken:     testIDToken(t, "secret", claims),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.UserID != "U1234" || id.Email != "" || !errors.Is(id.Errors[SourceIDToken], ErrIDTokenUserMismatch) {
+		t.Errorf("identity with another user's ID token = %+v", id)
+	}
+
+	if _, err := client.FetchIdentity(context.Background(), &TokenResponse{AccessToken: "token"}); err == nil {
+		t.Errorf("FetchIdentity without scopes succeeded")
+	}
+}
@@ -0,0 +1,456 @@
+package social
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/sha256"
+	b64 "encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// IDTokenIssuer is the iss claim of ID tokens issued by LINE Login.
+const IDTokenIssuer = "https://access.line.me"
+
+// ID token signing algorithms used by LINE Login. HS256 tokens are signed
+// with the channel secret; ES256 tokens with a key published as a JWKS.
+const (
+	AlgHS256 = "HS256"
+	AlgES256 = "ES256"
+)
+
+// ID token problem codes
+const (
+	ProblemExpired          = "expired"
+	ProblemIssuedInFuture   = "issued_in_future"
+	ProblemWrongAudience    = "wrong_audience"
+	ProblemWrongIssuer      = "wrong_issuer"
+	ProblemMissingNonce     = "missing_nonce"
+	ProblemNonceMismatch    = "nonce_mismatch"
+	ProblemUnsupportedAlg   = "unsupported_alg"
+	ProblemInvalidSignature = "invalid_signature"
+	ProblemUnknownKey       = "unknown_key"
+)
+
+// idTokenLeeway is the allowed clock skew for exp and iat.
+const idTokenLeeway = 5 * time.Minute
+
+// IDTokenHeader is the JOSE header of an ID token.
+type IDTokenHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// IDTokenProblem describes one failed check on an ID token.
+type IDTokenProblem struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// IDTokenError is returned when an ID token fails one or more checks.
+type IDTokenError struct {
+	Problems []IDTokenProblem
+}
+
+// Error method
+func (e *IDTokenError) Error() string {
+	msgs := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		msgs[i] = p.Message
+	}
+	return "id token verification failed: " + strings.Join(msgs, "; ")
+}
+
+// Has reports whether the error contains a problem with the given code.
+func (e *IDTokenError) Has(code string) bool {
+	for _, p := range e.Problems {
+		if p.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// InspectOptions configures InspectIDToken. Every field is optional; checks
+// that need a missing value are skipped.
+type InspectOptions struct {
+	// ChannelID is the expected aud claim.
+	ChannelID string
+	// Nonce is the expected nonce claim. When empty the nonce is not
+	// checked, since it is only sent when the authorization request had one.
+	Nonce string
+	// ChannelSecret verifies HS256 signatures.
+	ChannelSecret string
+	// PreviousSecrets are also accepted for HS256 signatures, e.g. while a
+	// rotated channel secret is in its grace period.
+	PreviousSecrets []string
+	// JWKS verifies ES256 signatures.
+	JWKS *JWKS
+	// Now is the time used for exp and iat checks. Default time.Now().
+	Now time.Time
+}
+
+// IDTokenInspection is the decoded content of an ID token and the result of
+// checking it.
+type IDTokenInspection struct {
+	Header  IDTokenHeader           `json:"header"`
+	Payload *LineProfilePlusPayload `json:"payload"`
+	// SignatureVerified is true when the signature was checked and is valid.
+	// It is false when no key was available for the token's alg.
+	SignatureVerified bool             `json:"signature_verified"`
+	Problems          []IDTokenProblem `json:"problems,omitempty"`
+
+	now time.Time
+}
+
+// InspectIDToken decodes idToken and checks its claims and, when a key is
+// available, its signature. It only returns an error when the token cannot
+// be decoded; failed checks are listed in Problems.
+func InspectIDToken(idToken string, opts InspectOptions) (*IDTokenInspection, error) {
+	segments := strings.Split(idToken, ".")
+	if len(segments) != 3 {
+		return nil, fmt.Errorf("idToken size is wrong")
+	}
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	ins := &IDTokenInspection{Payload: &LineProfilePlusPayload{}, now: now}
+	if err := decodeSegment(segments[0], &ins.Header); err != nil {
+		return nil, fmt.Errorf("header: %w", err)
+	}
+	if err := decodeSegment(segments[1], ins.Payload); err != nil {
+		return nil, fmt.Errorf("payload: %w", err)
+	}
+	signature, err := b64.RawURLEncoding.DecodeString(segments[2])
+	if err != nil {
+		return nil, fmt.Errorf("signature: base64url decode error: %w", err)
+	}
+
+	ins.checkSignature(segments[0]+"."+segments[1], signature, opts)
+	ins.checkClaims(opts)
+	return ins, nil
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := b64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("base64url decode error: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("json unmarshal error: %w", err)
+	}
+	return nil
+}
+
+// idTokenAudience returns the aud claim of idToken without verifying it.
+func idTokenAudience(idToken string) (string, error) {
+	segments := strings.Split(idToken, ".")
+	if len(segments) != 3 {
+		return "", fmt.Errorf("idToken size is wrong")
+	}
+	var claims struct {
+		Aud string `json:"aud"`
+	}
+	if err := decodeSegment(segments[1], &claims); err != nil {
+		return "", err
+	}
+	return claims.Aud, nil
+}
+
+func (ins *IDTokenInspection) addProblem(code, format string, args ...any) {
+	ins.Problems = append(ins.Problems, IDTokenProblem{Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+func (ins *IDTokenInspection) checkSignature(signingInput string, signature []byte, opts InspectOptions) {
+	switch ins.Header.Alg {
+	case AlgHS256:
+		if opts.ChannelSecret == "" {
+			return
+		}
+		for _, secret := range append([]string{opts.ChannelSecret}, opts.PreviousSecrets...) {
+			if verifyHS256(signingInput, signature, secret) {
+				ins.SignatureVerified = true
+				return
+			}
+		}
+		ins.addProblem(ProblemInvalidSignature, "HS256 signature does not match the channel secret")
+	case AlgES256:
+		if opts.JWKS == nil {
+			return
+		}
+		key, err := opts.JWKS.ecdsaKey(ins.Header.Kid)
+		if err != nil {
+			ins.addProblem(ProblemUnknownKey, "%v", err)
+			return
+		}
+		if !verifyES256(signingInput, signature, key) {
+			ins.addProblem(ProblemInvalidSignature, "ES256 signature does not match key %q", ins.Header.Kid)
+			return
+		}
+		ins.SignatureVerified = true
+	default:
+		ins.addProblem(ProblemUnsupportedAlg, "unsupported alg %q", ins.Header.Alg)
+	}
+}
+
+func (ins *IDTokenInspection) checkClaims(opts InspectOptions) {
+	p := ins.Payload
+	if p.Iss != IDTokenIssuer {
+		ins.addProblem(ProblemWrongIssuer, "wrong issuer %q, want %q", p.Iss, IDTokenIssuer)
+	}
+	if opts.ChannelID != "" && p.Aud != opts.ChannelID {
+		ins.addProblem(ProblemWrongAudience, "wrong audience %q, want channel ID %q", p.Aud, opts.ChannelID)
+	}
+	if exp := time.Unix(int64(p.Exp), 0); !ins.now.Before(exp.Add(idTokenLeeway)) {
+		ins.addProblem(ProblemExpired, "expired at %s", exp.UTC().Format(time.RFC3339))
+	}
+	if iat := time.Unix(int64(p.Iat), 0); iat.After(ins.now.Add(idTokenLeeway)) {
+		ins.addProblem(ProblemIssuedInFuture, "issued in the future at %s", iat.UTC().Format(time.RFC3339))
+	}
+	switch {
+	case opts.Nonce == "":
+	case p.Nonce == "":
+		ins.addProblem(ProblemMissingNonce, "missing nonce")
+	case p.Nonce != opts.Nonce:
+		ins.addProblem(ProblemNonceMismatch, "nonce %q does not match the authorization request", p.Nonce)
+	}
+}
+
+// Valid reports whether no problems were found.
+func (ins *IDTokenInspection) Valid() bool {
+	return len(ins.Problems) == 0
+}
+
+// Err returns an *IDTokenError listing the problems, or nil.
+func (ins *IDTokenInspection) Err() error {
+	if ins.Valid() {
+		return nil
+	}
+	return &IDTokenError{Problems: ins.Problems}
+}
+
+// WriteReport writes a human readable report of the inspection to w.
+func (ins *IDTokenInspection) WriteReport(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "Header")
+	fmt.Fprintf(tw, "  alg\t%s\n", ins.Header.Alg)
+	if ins.Header.Typ != "" {
+		fmt.Fprintf(tw, "  typ\t%s\n", ins.Header.Typ)
+	}
+	if ins.Header.Kid != "" {
+		fmt.Fprintf(tw, "  kid\t%s\n", ins.Header.Kid)
+	}
+
+	p := ins.Payload
+	fmt.Fprintln(tw, "Claims")
+	claim := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(tw, "  %s\t%s\n", name, value)
+		}
+	}
+	claim("iss", p.Iss)
+	claim("sub", p.Sub)
+	claim("aud", p.Aud)
+	claim("exp", ins.formatTime(p.Exp))
+	claim("iat", ins.formatTime(p.Iat))
+	claim("auth_time", ins.formatTime(p.AuthTime))
+	claim("nonce", p.Nonce)
+	claim("amr", strings.Join(p.Amr, " "))
+	claim("name", p.Name)
+	claim("picture", p.Picture)
+	claim("email", p.Email)
+	claim("given_name", p.GivenName)
+	claim("given_name_pronunciation", p.GivenNamePronunciation)
+	claim("middle_name", p.MiddleName)
+	claim("family_name", p.FamilyName)
+	claim("family_name_pronunciation", p.FamilyNamePronunciation)
+	claim("gender", p.Gender)
+	claim("birthdate", p.Birthdate)
+	claim("phone_number", p.PhoneNumber)
+	if p.Address != (Address{}) {
+		parts := []string{p.Address.PostalCode, p.Address.Region, p.Address.Locality, p.Address.StreetAddress, p.Address.Country}
+		claim("address", strings.Join(parts, ", "))
+	}
+
+	switch {
+	case ins.SignatureVerified:
+		fmt.Fprintf(tw, "Signature\tverified (%s)\n", ins.Header.Alg)
+	case (&IDTokenError{Problems: ins.Problems}).Has(ProblemInvalidSignature):
+		fmt.Fprintf(tw, "Signature\tINVALID (%s)\n", ins.Header.Alg)
+	default:
+		fmt.Fprintf(tw, "Signature\tnot verified (no key for %s)\n", ins.Header.Alg)
+	}
+	if len(ins.Problems) == 0 {
+		fmt.Fprintln(tw, "Problems\tnone")
+	} else {
+		fmt.Fprintln(tw, "Problems")
+		for _, problem := range ins.Problems {
+			fmt.Fprintf(tw, "  %s\t%s\n", problem.Code, problem.Message)
+		}
+	}
+	return tw.Flush()
+}
+
+// formatTime renders a NumericDate claim with its UTC time and distance from now.
+func (ins *IDTokenInspection) formatTime(sec int) string {
+	if sec == 0 {
+		return ""
+	}
+	t := time.Unix(int64(sec), 0)
+	d := t.Sub(ins.now).Round(time.Second)
+	rel := "in " + d.String()
+	if d < 0 {
+		rel = (-d).String() + " ago"
+	}
+	return fmt.Sprintf("%d (%s, %s)", sec, t.UTC().Format(time.RFC3339), rel)
+}
+
+func verifyHS256(signingInput string, signature []byte, secret string) bool {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signingInput))
+	return hmac.Equal(signature, mac.Sum(nil))
+}
+
+func verifyES256(signingInput string, signature []byte, key *ecdsa.PublicKey) bool {
+	if len(signature) != 64 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(signingInput))
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	return ecdsa.Verify(key, sum[:], r, s)
+}
+
+// ValidateIDToken checks an ID token offline: the signature with the channel
+// secret (HS256) or a JWKS (ES256), and the iss, aud, exp, iat and nonce
+// claims. Unlike VerifyIDToken it does not call the LINE Platform. With a
+// replay guard, a nonce is accepted only once.
+func (client *Client) ValidateIDToken(idToken, nonce string) *ValidateIDTokenCall {
+	return &ValidateIDTokenCall{
+		c:       client,
+		idToken: idToken,
+		nonce:   nonce,
+	}
+}
+
+// ValidateIDTokenCall type
+type ValidateIDTokenCall struct {
+	c   *Client
+	ctx context.Context
+
+	idToken string
+	nonce   string
+	jwks    *JWKS
+}
+
+// WithContext method
+func (call *ValidateIDTokenCall) WithContext(ctx context.Context) *ValidateIDTokenCall {
+	call.ctx = ctx
+	return call
+}
+
+// WithJWKS sets the key set used to verify ES256 signatures.
+func (call *ValidateIDTokenCall) WithJWKS(jwks *JWKS) *ValidateIDTokenCall {
+	call.jwks = jwks
+	return call
+}
+
+// Do method
+func (call *ValidateIDTokenCall) Do() (*LineProfilePlusPayload, error) {
+	payload, err := call.c.validateIDToken(call.idToken, call.nonce, call.jwks)
+	if err != nil {
+		return nil, err
+	}
+	// The nonce is only burnt once the token has passed every other check.
+	if payload.Nonce != "" {
+		ttl := time.Until(time.Unix(int64(payload.Exp), 0).Add(idTokenLeeway))
+		if err := call.c.useOnce(call.ctx, ReplayKindNonce, payload.Nonce, ttl); err != nil {
+			return nil, err
+		}
+	}
+	return payload, nil
+}
+
+// validateIDToken checks the signature and claims of an ID token without
+// recording its nonce with the replay guard.
+func (client *Client) validateIDToken(idToken, nonce string, jwks *JWKS) (*LineProfilePlusPayload, error) {
+	secrets, err := client.verificationSecrets()
+	if err != nil {
+		return nil, err
+	}
+	ins, err := InspectIDToken(idToken, InspectOptions{
+		ChannelID:       client.channelID,
+		Nonce:           nonce,
+		ChannelSecret:   secrets[0],
+		PreviousSecrets: secrets[1:],
+		JWKS:            jwks,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := ins.Err(); err != nil {
+		return nil, err
+	}
+	if !ins.SignatureVerified {
+		return nil, &IDTokenError{Problems: []IDTokenProblem{{
+			Code:    ProblemUnknownKey,
+			Message: fmt.Sprintf("no key available to verify the %s signature", ins.Header.Alg),
+		}}}
+	}
+	return ins.Payload, nil
+}
+
+// JWKS is a JSON Web Key Set, such as the one LINE publishes for ES256 ID tokens.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK is a JSON Web Key. Only EC P-256 keys are used.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg,omitempty"`
+	Use string `json:"use,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// ParseJWKS decodes a JSON Web Key Set.
+func ParseJWKS(data []byte) (*JWKS, error) {
+	jwks := &JWKS{}
+	if err := json.Unmarshal(data, jwks); err != nil {
+		return nil, fmt.Errorf("json unmarshal error: %w", err)
+	}
+	return jwks, nil
+}
+
+func (jwks *JWKS) ecdsaKey(kid string) (*ecdsa.PublicKey, error) {
+	for _, k := range jwks.Keys {
+		if k.Kid != kid {
+			continue
+		}
+		if k.Kty != "EC" || k.Crv != "P-256" {
+			return nil, fmt.Errorf("key %q is not an EC P-256 key", kid)
+		}
+		x, err := b64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := b64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, fmt.Errorf("no key with kid %q in JWKS", kid)
+}
@@ -0,0 +1,139 @@
+package social
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	b64 "encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testIDToken builds an HS256 ID token signed with secret.
+func testIDToken(t *testing.T, secret string, claims map[string]any) string {
+	t.Helper()
+	input := encodeTestSegment(t, map[string]any{"alg": "HS256", "typ": "JWT"}) + "." + encodeTestSegment(t, claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(input))
+	return input + "." + b64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func encodeTestSegment(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b64.RawURLEncoding.EncodeToString(data)
+}
+
+func testClaims(now time.Time) map[string]any {
+	return map[string]any{
+		"iss":   IDTokenIssuer,
+		"sub":   "U1234",
+		"aud":   "1234",
+		"exp":   now.Add(time.Hour).Unix(),
+		"iat":   now.Unix(),
+		"nonce": "nonce-1",
+		"amr":   []string{"pwd"},
+		"email": "brown@example.com",
+	}
+}
+
+func TestInspectIDTokenValid(t *testing.T) {
+	now := time.Now()
+	token := testIDToken(t, "secret", testClaims(now))
+	ins, err := InspectIDToken(token, InspectOptions{ChannelID: "1234", Nonce: "nonce-1", ChannelSecret: "secret", Now: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ins.Valid() || !ins.SignatureVerified {
+		t.Errorf("inspection = %+v", ins)
+	}
+	if ins.Payload.Email != "brown@example.com" {
+		t.Errorf("Email = %s", ins.Payload.Email)
+	}
+
+	var report strings.Builder
+	if err := ins.WriteReport(&report); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(report.String(), "verified (HS256)") {
+		t.Errorf("report = %s", report.String())
+	}
+}
+
+func TestInspectIDTokenProblems(t *testing.T) {
+	now := time.Now()
+	claims := testClaims(now)
+	claims["exp"] = now.Add(-time.Hour).Unix()
+	claims["iss"] = "https://example.com"
+	delete(claims, "nonce")
+	token := testIDToken(t, "secret", claims)
+
+	ins, err := InspectIDToken(token, InspectOptions{ChannelID: "5678", Nonce: "nonce-1", ChannelSecret: "other", Now: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var idErr *IDTokenError
+	if !errors.As(ins.Err(), &idErr) {
+		t.Fatalf("Err() = %v", ins.Err())
+	}
+	for _, code := range []string{ProblemExpired, ProblemWrongIssuer, ProblemWrongAudience, ProblemMissingNonce, ProblemInvalidSignature} {
+		if !idErr.Has(code) {
+			t.Errorf("missing problem %s in %v", code, idErr)
+		}
+	}
+
+	// A token without a nonce is fine when none was requested.
+	claims = testClaims(now)
+	delete(claims, "nonce")
+	ins, err = InspectIDToken(testIDToken(t, "secret", claims), InspectOptions{ChannelSecret: "secret", Now: now})
+	if err != nil || !ins.Valid() {
+		t.Errorf("InspectIDToken(no nonce expected) = %v, %v", ins.Problems, err)
+	}
+}
+
+func TestInspectIDTokenES256(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	input := encodeTestSegment(t, map[string]any{"alg": "ES256", "kid": "k1"}) + "." + encodeTestSegment(t, testClaims(now))
+	sum := sha256.Sum256([]byte(input))
+	r, s, err := ecdsa.Sign(rand.Reader, key, sum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	token := input + "." + b64.RawURLEncoding.EncodeToString(sig)
+
+	jwks := &JWKS{Keys: []JWK{{
+		Kty: "EC",
+		Kid: "k1",
+		Crv: "P-256",
+		X:   b64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		Y:   b64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+	}}}
+	client, _ := New("1234", "secret")
+	payload, err := client.ValidateIDToken(token, "nonce-1").WithJWKS(jwks).Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload.Sub != "U1234" {
+		t.Errorf("Sub = %s", payload.Sub)
+	}
+
+	// Without the key set the signature cannot be checked.
+	if _, err := client.ValidateIDToken(token, "nonce-1").Do(); err == nil {
+		t.Errorf("ValidateIDToken without JWKS succeeded")
+	}
+}
@@ -0,0 +1,176 @@
+package social
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LIFFOptions configures LIFFVerifier.
+type LIFFOptions struct {
+	// ChannelIDs lists the channels of your LIFF apps. The client's own
+	// channel is used when empty.
+	ChannelIDs []string
+	// MinRemaining rejects tokens that expire sooner than this.
+	MinRemaining time.Duration
+	// RequiredScopes must all have been granted to the access token.
+	RequiredScopes []string
+}
+
+// LIFFIdentity is the user of a LIFF app, merged from the access token and
+// the ID token.
+type LIFFIdentity struct {
+	UserID    string
+	Name      string
+	Picture   string
+	Email     string
+	ChannelID string
+	Scopes    []string
+	// ExpiresAt is when the access token expires.
+	ExpiresAt time.Time
+}
+
+// ChannelMismatchError is returned when a LIFF token was issued for a
+// channel other than the expected ones. It matches ErrClientNotAllowed with
+// errors.Is.
+type ChannelMismatchError struct {
+	// Token is "access_token" or "id_token".
+	Token    string
+	Expected []string
+	Actual   string
+}
+
+// Error method
+func (e *ChannelMismatchError) Error() string {
+	return fmt.Sprintf("%s: %s issued for channel %s, want one of %s",
+		ErrClientNotAllowed, e.Token, e.Actual, strings.Join(e.Expected, ", "))
+}
+
+// Is method
+func (e *ChannelMismatchError) Is(target error) bool {
+	return target == ErrClientNotAllowed
+}
+
+// TokenLifetimeError is returned when a LIFF token expires sooner than
+// LIFFOptions.MinRemaining. It matches ErrTokenExpiring with errors.Is.
+type TokenLifetimeError struct {
+	// Token is "access_token" or "id_token".
+	Token     string
+	Remaining time.Duration
+	Minimum   time.Duration
+}
+
+// Error method
+func (e *TokenLifetimeError) Error() string {
+	return fmt.Sprintf("%s: %s expires in %s, want at least %s", ErrTokenExpiring, e.Token, e.Remaining, e.Minimum)
+}
+
+// Is method
+func (e *TokenLifetimeError) Is(target error) bool {
+	return target == ErrTokenExpiring
+}
+
+// LIFFVerifier checks tokens that a LIFF app sends to your server.
+type LIFFVerifier struct {
+	client  *Client
+	options LIFFOptions
+	now     func() time.Time
+}
+
+// LIFFVerifier returns a verifier for the LIFF apps of the given channels.
+func (client *Client) LIFFVerifier(options LIFFOptions) *LIFFVerifier {
+	if len(options.ChannelIDs) == 0 {
+		options.ChannelIDs = []string{client.channelID}
+	}
+	return &LIFFVerifier{client: client, options: options, now: time.Now}
+}
+
+// Verify checks the access token from liff.getAccessToken() and, when not
+// empty, the ID token from liff.getIDToken(). Both must be issued for one of
+// the expected channels, for the same channel and for the same user. The user
+// comes from the profile, or from userinfo with only the openid scope; when
+// no scope identifies the user and there is no ID token, ErrUnknownUser is
+// returned.
+func (v *LIFFVerifier) Verify(ctx context.Context, accessToken, idToken string) (*LIFFIdentity, error) {
+	verified, err := v.client.TokenVerify(accessToken).WithContext(ctx).Do()
+	if err != nil {
+		return nil, err
+	}
+	if !v.expected(verified.ClientID) {
+		return nil, &ChannelMismatchError{Token: "access_token", Expected: v.options.ChannelIDs, Actual: verified.ClientID}
+	}
+	remaining := time.Duration(verified.ExpiresIn) * time.Second
+	if remaining < v.options.MinRemaining {
+		return nil, &TokenLifetimeError{Token: "access_token", Remaining: remaining, Minimum: v.options.MinRemaining}
+	}
+	scopes := verified.Scopes()
+	if missing := scopes.Missing(v.options.RequiredScopes...); len(missing) > 0 {
+		return nil, &MissingScopeError{Missing: missing}
+	}
+	now := v.now()
+	identity := &LIFFIdentity{
+		ChannelID: verified.ClientID,
+		Scopes:    scopes.Slice(),
+		ExpiresAt: now.Add(remaining),
+	}
+
+	var profile *GetUserProfileResponse
+	if scopes.Has(ScopeProfile) {
+		profile, err = v.client.GetUserProfile(accessToken).WithContext(ctx).Do()
+		if err != nil {
+			return nil, err
+		}
+		identity.UserID = profile.UserID
+		identity.Name = profile.DisplayName
+		identity.Picture = profile.PictureURL
+	} else if scopes.Has(ScopeOpenID) {
+		info, err := v.client.GetUserInfo(accessToken).WithContext(ctx).Do()
+		if err != nil {
+			return nil, err
+		}
+		identity.UserID = info.Sub
+		identity.Name = info.Name
+		identity.Picture = info.Picture
+	}
+
+	if idToken != "" {
+		aud, err := idTokenAudience(idToken)
+		if err != nil {
+			return nil, err
+		}
+		if aud != verified.ClientID {
+			return nil, &ChannelMismatchError{Token: "id_token", Expected: []string{verified.ClientID}, Actual: aud}
+		}
+		options := VerifyIDTokenRequestOptions{ClientID: aud, UserID: identity.UserID}
+		payload, err := v.client.VerifyIDToken(idToken, options).WithContext(ctx).Do()
+		if err != nil {
+			return nil, err
+		}
+		remaining := time.Unix(int64(payload.Exp), 0).Sub(now)
+		if remaining < v.options.MinRemaining {
+			return nil, &TokenLifetimeError{Token: "id_token", Remaining: remaining, Minimum: v.options.MinRemaining}
+		}
+		identity.UserID = payload.Sub
+		identity.Email = payload.Email
+		if identity.Name == "" {
+			identity.Name = payload.Name
+		}
+		if identity.Picture == "" {
+			identity.Picture = payload.Picture
+		}
+	}
+	if identity.UserID == "" {
+		return nil, ErrUnknownUser
+	}
+	return identity, nil
+}
+
+func (v *LIFFVerifier) expected(channelID string) bool {
+	for _, id := range v.options.ChannelIDs {
+		if id == channelID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package social
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLIFFVerifier(t *testing.T) {
+	now := time.Now()
+	var verifiedClientID, verifiedUserID string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == APIEndpointTokenVerify && r.Method == http.MethodGet:
+			switch r.URL.Query().Get("access_token") {
+			case "liff":
+				fmt.Fprint(w, `{"scope":"openid profile email","client_id":"1234","expires_in":3600}`)
+			case "short":
+				fmt.Fprint(w, `{"scope":"openid profile","client_id":"1234","expires_in":30}`)
+			case "other":
+				fmt.Fprint(w, `{"scope":"openid profile","client_id":"9999","expires_in":3600}`)
+			case "openid":
+				fmt.Fprint(w, `{"scope":"openid","client_id":"1234","expires_in":3600}`)
+			case "anonymous":
+				fmt.Fprint(w, `{"scope":"chat_message.write","client_id":"1234","expires_in":3600}`)
+			}
+		case r.URL.Path == APIEndpointTokenVerify:
+			r.ParseForm()
+			verifiedClientID = r.Form.Get("client_id")
+			verifiedUserID = r.Form.Get("user_id")
+			fmt.Fprintf(w, `{"sub":"U1234","aud":"1234","exp":%d,"email":"brown@example.com"}`, now.Add(time.Hour).Unix())
+		case r.URL.Path == APIEndpointUserInfo:
+			fmt.Fprint(w, `{"sub":"U5678"}`)
+		case r.URL.Path == APIEndpointGetUserProfile:
+			fmt.Fprint(w, `{"userId":"U1234","displayName":"Brown","pictureUrl":"https://profile.line-scdn.net/abc"}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	// The client belongs to another channel; the LIFF channel is configured explicitly.
+	client, err := New("5678", "secret", WithEndpointBase(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := client.LIFFVerifier(LIFFOptions{
+		ChannelIDs:     []string{"1234"},
+		MinRemaining:   time.Minute,
+		RequiredScopes: []string{"openid"},
+	})
+	v.now = func() time.Time { return now }
+	idToken := testIDToken(t, "liff-secret", testClaims(now))
+
+	identity, err := v.Verify(context.Background(), "liff", idToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.UserID != "U1234" || identity.Name != "Brown" || identity.Email != "brown@example.com" || identity.ChannelID != "1234" {
+		t.Errorf("identity = %+v", identity)
+	}
+	if verifiedClientID != "1234" || verifiedUserID != "U1234" {
+		t.Errorf("VerifyIDToken sent client_id=%q user_id=%q", verifiedClientID, verifiedUserID)
+	}
+
+	_, err = v.Verify(context.Background(), "other", "")
+	var mismatch *ChannelMismatchError
+	if !errors.As(err, &mismatch) || mismatch.Actual != "9999" || !errors.Is(err, ErrClientNotAllowed) {
+		t.Errorf("Verify(other channel) = %v", err)
+	}
+
+	claims := testClaims(now)
+	claims["aud"] = "9999"
+	_, err = v.Verify(context.Background(), "liff", testIDToken(t, "liff-secret", claims))
+	if !errors.As(err, &mismatch) || mismatch.Token != "id_token" {
+		t.Errorf("Verify(ID token of other channel) = %v", err)
+	}
+
+	_, err = v.Verify(context.Background(), "short", "")
+	var lifetime *TokenLifetimeError
+	if !errors.As(err, &lifetime) || lifetime.Remaining != 30*time.Second {
+		t.Errorf("Verify(short-lived) = %v", err)
+	}
+
+	// Without the profile scope the user comes from userinfo.
+	identity, err = v.Verify(context.Background(), "openid", "")
+	if err != nil || identity.UserID != "U5678" {
+		t.Errorf("Verify(openid only) = %+v, %v", identity, err)
+	}
+
+	v.options.RequiredScopes = nil
+	if _, err := v.Verify(context.Background(), "anonymous", ""); !errors.Is(err, ErrUnknownUser) {
+		t.Errorf("Verify(no user scope) = %v", err)
+	}
+
+	v.options.RequiredScopes = []string{"openid", "chat_message.write"}
+	_, err = v.Verify(context.Background(), "liff", "")
+	var scopeErr *MissingScopeError
+	if !errors.As(err, &scopeErr) || len(scopeErr.Missing) != 1 || scopeErr.Missing[0] != "chat_message.write" {
+		t.Errorf("Verify(missing scope) = %v", err)
+	}
+}
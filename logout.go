@@ -0,0 +1,228 @@
+package social
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// Logout steps
+const (
+	LogoutStepSession     = "session"
+	LogoutStepDeauthorize = "deauthorize"
+	LogoutStepRevoke      = "revoke"
+	LogoutStepClearStore  = "clear_store"
+	LogoutStepClearCookie = "clear_cookie"
+	// LogoutStepClearLogin expires the LoginBinding cookie, so login states
+	// still pending in the StateStore can no longer be completed by this
+	// browser. The entries themselves expire after their TTL.
+	LogoutStepClearLogin = "clear_login"
+)
+
+// LogoutRequest describes what Logout undoes. LINE Login has no logout
+// endpoint, so logging out means revoking the access token and forgetting
+// it locally.
+type LogoutRequest struct {
+	AccessToken string
+	// Store, when not nil, has its token deleted.
+	Store TokenStore
+	// Deauthorize also revokes all permissions the user granted to the
+	// channel, e.g. for a "disconnect LINE" action. It requires
+	// ChannelAccessToken.
+	Deauthorize        bool
+	ChannelAccessToken string
+}
+
+// LogoutStep is the outcome of one logout step.
+type LogoutStep struct {
+	Name    string
+	Skipped bool
+	Err     error
+}
+
+// LogoutResult lists the steps of a logout in order.
+type LogoutResult struct {
+	Steps []LogoutStep
+	// ReturnTo is where LogoutHandler redirected to.
+	ReturnTo string
+}
+
+// Succeeded reports whether the step ran without error.
+func (r *LogoutResult) Succeeded(name string) bool {
+	for _, s := range r.Steps {
+		if s.Name == name {
+			return !s.Skipped && s.Err == nil
+		}
+	}
+	return false
+}
+
+// Err returns the errors of the failed steps, or nil.
+func (r *LogoutResult) Err() error {
+	var errs []error
+	for _, s := range r.Steps {
+		if s.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.Name, s.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *LogoutResult) add(name string, skipped bool, err error) {
+	r.Steps = append(r.Steps, LogoutStep{Name: name, Skipped: skipped, Err: err})
+}
+
+// Logout deauthorizes the user when requested, revokes the access token and
+// deletes the stored token. Every step runs even when an earlier one
+// failed, so the local session is cleared regardless. Revoking is skipped
+// after a successful deauthorization, which already revoked the token.
+func (client *Client) Logout(ctx context.Context, req LogoutRequest) *LogoutResult {
+	result := &LogoutResult{}
+	deauthorized := false
+	if req.Deauthorize {
+		var err error
+		switch {
+		case req.ChannelAccessToken == "":
+			err = errors.New("missing channel access token")
+		case req.AccessToken == "":
+			err = ErrMissingToken
+		default:
+			_, err = client.Deauthorize(req.ChannelAccessToken, req.AccessToken).WithContext(ctx).Do()
+		}
+		deauthorized = err == nil
+		result.add(LogoutStepDeauthorize, false, err)
+	}
+
+	if req.AccessToken == "" || deauthorized {
+		result.add(LogoutStepRevoke, true, nil)
+	} else {
+		_, err := client.RevokeToken(req.AccessToken).WithContext(ctx).Do()
+		result.add(LogoutStepRevoke, false, err)
+	}
+
+	if req.Store == nil {
+		result.add(LogoutStepClearStore, true, nil)
+	} else {
+		result.add(LogoutStepClearStore, false, req.Store.DeleteToken(ctx))
+	}
+	return result
+}
+
+// LogoutHandlerOptions configures LogoutHandler.
+type LogoutHandlerOptions struct {
+	// Session returns the access token and token store of the request's
+	// session. The token may be empty when the session is already gone.
+	Session func(r *http.Request) (accessToken string, store TokenStore, err error)
+	// CookieName is the session cookie to clear. Empty leaves cookies alone.
+	CookieName   string
+	CookiePath   string // default "/"
+	CookieDomain string
+	// Deauthorize reports whether the request is a "disconnect LINE"
+	// action. Nil never deauthorizes.
+	Deauthorize        func(r *http.Request) bool
+	ChannelAccessToken string
+	// DefaultReturnTo is used without a valid signed return_to. Default "/".
+	DefaultReturnTo string
+	// OnResult, when not nil, receives the result of every logout.
+	OnResult func(r *http.Request, result *LogoutResult)
+	// AllowedOrigins are accepted in the Origin header in addition to the
+	// request's own origin, e.g. "https://www.example.com" behind a proxy
+	// that terminates TLS.
+	AllowedOrigins []string
+	// CSRF, when not nil, is an additional check such as a CSRF token
+	// comparison. A non-nil error rejects the request with 403.
+	CSRF func(r *http.Request) error
+}
+
+// sameOrigin reports whether a browser sent r from a page of this site. The
+// Origin header is compared when present, otherwise Sec-Fetch-Site must be
+// same-origin; requests with neither header are rejected.
+func (opts *LogoutHandlerOptions) sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return r.Header.Get("Sec-Fetch-Site") == "same-origin"
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return origin == scheme+"://"+r.Host || slices.Contains(opts.AllowedOrigins, origin)
+}
+
+// LogoutHandler returns an http.Handler that logs out on POST and redirects
+// with 303 to the return_to form value, which must be signed with
+// SignReturnTo, or to DefaultReturnTo. Cross-site requests are rejected with
+// 403 before anything is revoked.
+func (client *Client) LogoutHandler(opts LogoutHandlerOptions) http.Handler {
+	if opts.CookiePath == "" {
+		opts.CookiePath = "/"
+	}
+	if opts.DefaultReturnTo == "" {
+		opts.DefaultReturnTo = "/"
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		if !opts.sameOrigin(r) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		if opts.CSRF != nil {
+			if err := opts.CSRF(r); err != nil {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+		}
+		req := LogoutRequest{ChannelAccessToken: opts.ChannelAccessToken}
+		var sessionErr error
+		if opts.Session != nil {
+			req.AccessToken, req.Store, sessionErr = opts.Session(r)
+		}
+		req.Deauthorize = opts.Deauthorize != nil && opts.Deauthorize(r)
+
+		result := client.Logout(r.Context(), req)
+		if sessionErr != nil {
+			result.Steps = append([]LogoutStep{{Name: LogoutStepSession, Err: sessionErr}}, result.Steps...)
+		}
+		if opts.CookieName == "" {
+			result.add(LogoutStepClearCookie, true, nil)
+		} else {
+			http.SetCookie(w, &http.Cookie{
+				Name:     opts.CookieName,
+				Path:     opts.CookiePath,
+				Domain:   opts.CookieDomain,
+				Expires:  time.Unix(0, 0),
+				MaxAge:   -1,
+				HttpOnly: true,
+				Secure:   r.TLS != nil,
+			})
+			result.add(LogoutStepClearCookie, false, nil)
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name:     LoginBindingCookie,
+			Path:     "/",
+			Expires:  time.Unix(0, 0),
+			MaxAge:   -1,
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+		})
+		result.add(LogoutStepClearLogin, false, nil)
+
+		result.ReturnTo = opts.DefaultReturnTo
+		if signed := r.FormValue("return_to"); signed != "" {
+			if returnTo, err := client.VerifyReturnTo(signed); err == nil {
+				result.ReturnTo = returnTo
+			}
+		}
+		if opts.OnResult != nil {
+			opts.OnResult(r, result)
+		}
+		http.Redirect(w, r, result.ReturnTo, http.StatusSeeOther)
+	})
+}
@@ -0,0 +1,170 @@
+package social
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newLogoutServer(t *testing.T, calls *[]string) *Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls = append(*calls, r.URL.Path)
+		switch r.URL.Path {
+		case APIEndpointRevokeToken:
+			w.WriteHeader(http.StatusOK)
+		case APIEndpointDeauthorize:
+			if r.Header.Get("Authorization") != "Bearer channel-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	client, err := New("1234", "secret", WithEndpointBase(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestLogout(t *testing.T) {
+	var calls []string
+	client := newLogoutServer(t, &calls)
+	ctx := context.Background()
+
+	store := &testTokenStore{}
+	result := client.Logout(ctx, LogoutRequest{AccessToken: "access", Store: store})
+	if result.Err() != nil || !result.Succeeded(LogoutStepRevoke) || !result.Succeeded(LogoutStepClearStore) || !store.deleted {
+		t.Errorf("Logout = %+v", result)
+	}
+	if result.Succeeded(LogoutStepDeauthorize) {
+		t.Errorf("deauthorize ran without being requested")
+	}
+
+	calls = nil
+	result = client.Logout(ctx, LogoutRequest{AccessToken: "access", Deauthorize: true, ChannelAccessToken: "channel-token"})
+	if !result.Succeeded(LogoutStepDeauthorize) || result.Succeeded(LogoutStepRevoke) || len(calls) != 1 {
+		t.Errorf("Logout(deauthorize) = %+v, calls = %v", result, calls)
+	}
+
+	// A failed deauthorization still revokes and clears the session.
+	calls = nil
+	store = &testTokenStore{}
+	result = client.Logout(ctx, LogoutRequest{AccessToken: "access", Store: store, Deauthorize: true, ChannelAccessToken: "wrong"})
+	var apiErr *APIError
+	if !errors.As(result.Err(), &apiErr) || !result.Succeeded(LogoutStepRevoke) || !store.deleted {
+		t.Errorf("Logout(failed deauthorize) = %+v", result)
+	}
+}
+
+func TestLogoutHandler(t *testing.T) {
+	var calls []string
+	client := newLogoutServer(t, &calls)
+	store := &testTokenStore{}
+	var result *LogoutResult
+	h := client.LogoutHandler(LogoutHandlerOptions{
+		Session: func(r *http.Request) (string, TokenStore, error) {
+			return "access", store, nil
+		},
+		CookieName:         "sid",
+		Deauthorize:        func(r *http.Request) bool { return r.FormValue("disconnect") == "1" },
+		ChannelAccessToken: "channel-token",
+		OnResult:           func(r *http.Request, res *LogoutResult) { result = res },
+		AllowedOrigins:     []string{"https://www.example.com"},
+	})
+
+	signed, err := client.SignReturnTo("/goodbye", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		form     url.Values
+		header   string
+		value    string
+		location string
+		steps    string
+	}{
+		{url.Values{"return_to": {signed}}, "Origin", "http://example.com", "/goodbye", "revoke clear_store clear_cookie clear_login"},
+		{url.Values{"return_to": {"/evil"}, "disconnect": {"1"}}, "Sec-Fetch-Site", "same-origin", "/", "deauthorize clear_store clear_cookie clear_login"},
+		{url.Values{}, "Origin", "https://www.example.com", "/", "revoke clear_store clear_cookie clear_login"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/logout", strings.NewReader(tt.form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set(tt.header, tt.value)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != tt.location {
+			t.Errorf("%v: status = %d, Location = %q", tt.form, rec.Code, rec.Header().Get("Location"))
+		}
+		cookies := rec.Header().Values("Set-Cookie")
+		if len(cookies) != 2 || !strings.HasPrefix(cookies[0], "sid=;") || !strings.Contains(cookies[0], "Max-Age=0") ||
+			!strings.HasPrefix(cookies[1], LoginBindingCookie+"=;") {
+			t.Errorf("Set-Cookie = %q", cookies)
+		}
+		var succeeded []string
+		for _, s := range result.Steps {
+			if result.Succeeded(s.Name) {
+				succeeded = append(succeeded, s.Name)
+			}
+		}
+		if got := strings.Join(succeeded, " "); got != tt.steps {
+			t.Errorf("%v: succeeded steps = %s, want %s", tt.form, got, tt.steps)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/logout", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET status = %d", rec.Code)
+	}
+
+	// Cross-site requests revoke nothing.
+	calls = nil
+	for _, header := range []http.Header{
+		{"Origin": {"https://evil.com"}},
+		{"Sec-Fetch-Site": {"cross-site"}},
+		{"Sec-Fetch-Site": {"same-site"}},
+		{},
+	} {
+		req := httptest.NewRequest("POST", "/logout", nil)
+		req.Header = header
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("%v: status = %d", header, rec.Code)
+		}
+	}
+	if len(calls) != 0 {
+		t.Errorf("cross-site calls = %v", calls)
+	}
+
+	// The CSRF check runs after the origin check.
+	h = client.LogoutHandler(LogoutHandlerOptions{
+		CSRF: func(r *http.Request) error {
+			if r.FormValue("csrf") != "token" {
+				return errors.New("bad CSRF token")
+			}
+			return nil
+		},
+	})
+	for form, code := range map[string]int{"csrf=token": http.StatusSeeOther, "csrf=other": http.StatusForbidden} {
+		req := httptest.NewRequest("POST", "/logout", strings.NewReader(form))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Origin", "http://example.com")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != code {
+			t.Errorf("%s: status = %d, want %d", form, rec.Code, code)
+		}
+	}
+}
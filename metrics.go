@@ -0,0 +1,43 @@
+package social
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Metrics receives one observation per HTTP attempt made by a client.
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	// ObserveRequest is called after each attempt. statusCode is 0 when err
+	// is a network error.
+	ObserveRequest(channelID, endpoint string, statusCode int, err error, duration time.Duration)
+}
+
+// WithMetrics function
+func WithMetrics(m Metrics) ClientOption {
+	return func(client *Client) error {
+		client.metrics = m
+		return nil
+	}
+}
+
+func (client *Client) observe(req *http.Request, res *http.Response, err error, d time.Duration) {
+	if client.metrics == nil {
+		return
+	}
+	status := 0
+	if res != nil {
+		status = res.StatusCode
+	}
+	client.metrics.ObserveRequest(client.channelID, metricsEndpoint(req.URL.Path), status, err, d)
+}
+
+// metricsEndpoint replaces user IDs in request paths so that the endpoint
+// label stays bounded.
+func metricsEndpoint(p string) string {
+	if rest, ok := strings.CutPrefix(p, "/v2/bot/user/"); ok && strings.HasSuffix(rest, "/linkToken") {
+		return "/v2/bot/user/{userId}/linkToken"
+	}
+	return p
+}
@@ -0,0 +1,298 @@
+package social
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PictureSize selects a variant of a profile picture on LINE's CDN.
+type PictureSize string
+
+// PictureSize values
+const (
+	PictureOriginal PictureSize = ""
+	PictureLarge    PictureSize = "large" // 200x200
+	PictureSmall    PictureSize = "small" // 51x51
+)
+
+// PictureURLVariant returns the URL of the given size of a profile picture.
+// Any size suffix already present is replaced.
+func PictureURLVariant(pictureURL string, size PictureSize) string {
+	if pictureURL == "" {
+		return ""
+	}
+	base := strings.TrimSuffix(pictureURL, "/")
+	for _, s := range []PictureSize{PictureLarge, PictureSmall} {
+		base = strings.TrimSuffix(base, "/"+string(s))
+	}
+	if size == PictureOriginal {
+		return base
+	}
+	return base + "/" + string(size)
+}
+
+// PictureURLSize returns the URL of the given size of the profile picture.
+func (r *GetUserProfileResponse) PictureURLSize(size PictureSize) string {
+	return PictureURLVariant(r.PictureURL, size)
+}
+
+// ProfileImage is a downloaded profile picture.
+type ProfileImage struct {
+	UserID      string
+	URL         string
+	ContentType string
+	Data        []byte
+	FetchedAt   time.Time
+}
+
+// ImageCache stores profile images. Keys change whenever the user's picture
+// URL changes, so entries never need to be invalidated.
+type ImageCache interface {
+	Get(key string) (*ProfileImage, bool)
+	Set(key string, img *ProfileImage) error
+}
+
+// ProfileImageOptions configures ProfileImageFetcher.
+type ProfileImageOptions struct {
+	// Cache stores downloaded images. Nothing is cached when nil.
+	Cache ImageCache
+	// Size is the variant to download. Default PictureLarge.
+	Size PictureSize
+	// MaxBytes limits the image size. Default 1 MiB.
+	MaxBytes int64
+	// AllowedHosts lists the hosts pictures may be downloaded from; a
+	// leading "." matches subdomains. Default ".line-scdn.net".
+	AllowedHosts []string
+}
+
+// ProfileImageFetcher downloads profile pictures with the client's HTTP
+// client, so they can be served from your own origin. Redirects are followed
+// only to allowed hosts.
+type ProfileImageFetcher struct {
+	client     *Client
+	options    ProfileImageOptions
+	httpClient *http.Client
+}
+
+// ProfileImageFetcher returns a fetcher for profile pictures.
+func (client *Client) ProfileImageFetcher(options ProfileImageOptions) *ProfileImageFetcher {
+	if options.Size == PictureOriginal {
+		options.Size = PictureLarge
+	}
+	if options.MaxBytes <= 0 {
+		options.MaxBytes = 1 << 20
+	}
+	if len(options.AllowedHosts) == 0 {
+		options.AllowedHosts = []string{".line-scdn.net"}
+	}
+	f := &ProfileImageFetcher{client: client, options: options}
+	httpClient := *client.httpClient
+	checkRedirect := httpClient.CheckRedirect
+	httpClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if err := f.checkURL(req.URL.String()); err != nil {
+			return err
+		}
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	f.httpClient = &httpClient
+	return f
+}
+
+// allowedImageTypes are the content types accepted as profile pictures.
+var allowedImageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// Fetch returns the profile picture of userID at pictureURL, from the cache
+// when possible.
+func (f *ProfileImageFetcher) Fetch(ctx context.Context, userID, pictureURL string) (*ProfileImage, error) {
+	imageURL := PictureURLVariant(pictureURL, f.options.Size)
+	if err := f.checkURL(imageURL); err != nil {
+		return nil, err
+	}
+	key := profileImageKey(userID, imageURL)
+	if f.options.Cache != nil {
+		if img, ok := f.options.Cache.Get(key); ok {
+			if img.URL == "" {
+				cached := *img
+				cached.UserID, cached.URL = userID, imageURL
+				img = &cached
+			}
+			return img, nil
+		}
+	}
+
+	req, err := http.NewRequest("GET", imageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "API-Service-Go/"+version)
+	if ctx != nil {
+		req = req.WithContext(ctx)
+	}
+	res, err := f.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, &APIError{Code: res.StatusCode}
+	}
+	if res.ContentLength > f.options.MaxBytes {
+		return nil, fmt.Errorf("%w: %d bytes", ErrImageTooLarge, res.ContentLength)
+	}
+	data, err := io.ReadAll(io.LimitReader(res.Body, f.options.MaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > f.options.MaxBytes {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrImageTooLarge, f.options.MaxBytes)
+	}
+	// The declared type must be an image and agree with the content.
+	declared, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	sniffed := http.DetectContentType(data)
+	if !allowedImageTypes[declared] || declared != sniffed {
+		return nil, fmt.Errorf("%w: %q (content looks like %q)", ErrUnsupportedImageType, declared, sniffed)
+	}
+
+	img := &ProfileImage{
+		UserID:      userID,
+		URL:         imageURL,
+		ContentType: declared,
+		Data:        data,
+		FetchedAt:   time.Now(),
+	}
+	if f.options.Cache != nil {
+		if err := f.options.Cache.Set(key, img); err != nil {
+			return img, err
+		}
+	}
+	return img, nil
+}
+
+func (f *ProfileImageFetcher) checkURL(imageURL string) error {
+	u, err := url.Parse(imageURL)
+	if err != nil || u.Scheme != "https" || u.User != nil {
+		return fmt.Errorf("%w: %q", ErrImageHostNotAllowed, imageURL)
+	}
+	host := u.Hostname()
+	for _, allowed := range f.options.AllowedHosts {
+		if host == allowed || strings.HasPrefix(allowed, ".") && strings.HasSuffix(host, allowed) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q", ErrImageHostNotAllowed, host)
+}
+
+func profileImageKey(userID, imageURL string) string {
+	sum := sha256.Sum256([]byte(userID + "\x00" + imageURL))
+	return hex.EncodeToString(sum[:])
+}
+
+// MemoryImageCache is an ImageCache in memory holding up to a fixed number
+// of images. The oldest entry is evicted first.
+type MemoryImageCache struct {
+	mu      sync.Mutex
+	max     int
+	order   []string
+	entries map[string]*ProfileImage
+}
+
+// NewMemoryImageCache returns a cache for up to maxEntries images.
+func NewMemoryImageCache(maxEntries int) *MemoryImageCache {
+	if maxEntries <= 0 {
+		maxEntries = 1000
+	}
+	return &MemoryImageCache{max: maxEntries, entries: map[string]*ProfileImage{}}
+}
+
+// Get method
+func (c *MemoryImageCache) Get(key string) (*ProfileImage, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	img, ok := c.entries[key]
+	return img, ok
+}
+
+// Set method
+func (c *MemoryImageCache) Set(key string, img *ProfileImage) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok {
+		c.order = append(c.order, key)
+	}
+	c.entries[key] = img
+	for len(c.order) > c.max {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+	return nil
+}
+
+// DiskImageCache is an ImageCache storing one file per image in a directory.
+// The first line of each file is the content type.
+type DiskImageCache struct {
+	Dir string
+}
+
+// Get method
+func (c *DiskImageCache) Get(key string) (*ProfileImage, bool) {
+	path := filepath.Join(c.Dir, key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	contentType, body, ok := strings.Cut(string(data), "\n")
+	if !ok || !allowedImageTypes[contentType] {
+		return nil, false
+	}
+	img := &ProfileImage{ContentType: contentType, Data: []byte(body)}
+	if fi, err := os.Stat(path); err == nil {
+		img.FetchedAt = fi.ModTime()
+	}
+	return img, true
+}
+
+// Set method
+func (c *DiskImageCache) Set(key string, img *ProfileImage) error {
+	if err := os.MkdirAll(c.Dir, 0o700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(c.Dir, key+".tmp*")
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(f, img.ContentType+"\n")
+	if err == nil {
+		_, err = f.Write(img.Data)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(c.Dir, key))
+}
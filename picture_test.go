@@ -0,0 +1,97 @@
+package social
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPictureURLVariant(t *testing.T) {
+	const base = "https://profile.line-scdn.net/0hAbCd"
+	tests := []struct {
+		in   string
+		size PictureSize
+		want string
+	}{
+		{base, PictureLarge, base + "/large"},
+		{base + "/large", PictureSmall, base + "/small"},
+		{base + "/small", PictureOriginal, base},
+		{"", PictureLarge, ""},
+	}
+	for _, tt := range tests {
+		if got := PictureURLVariant(tt.in, tt.size); got != tt.want {
+			t.Errorf("PictureURLVariant(%q, %q) = %q, want %q", tt.in, tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestProfileImageFetcher(t *testing.T) {
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("x", 100)
+	var calls int
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch r.URL.Path {
+		case "/avatar/large":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte(png))
+		case "/html/large":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("<html><script>alert(1)</script></html>"))
+		case "/moved/large":
+			http.Redirect(w, r, "/avatar/large", http.StatusFound)
+		case "/escape/large":
+			http.Redirect(w, r, "https://evil.example/avatar", http.StatusFound)
+		case "/huge/large":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte(png + strings.Repeat("x", 2000)))
+		}
+	}))
+	defer srv.Close()
+	client, err := New("1234", "secret", WithHTTPClient(srv.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, cache := range []ImageCache{NewMemoryImageCache(10), &DiskImageCache{Dir: t.TempDir()}} {
+		calls = 0
+		f := client.ProfileImageFetcher(ProfileImageOptions{Cache: cache, MaxBytes: 1024, AllowedHosts: []string{"127.0.0.1"}})
+		for i := 0; i < 2; i++ {
+			img, err := f.Fetch(context.Background(), "U1234", srv.URL+"/avatar")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if img.ContentType != "image/png" || string(img.Data) != png || img.URL != srv.URL+"/avatar/large" {
+				t.Errorf("%T: image = %s %q", cache, img.ContentType, img.URL)
+			}
+		}
+		if calls != 1 {
+			t.Errorf("%T: downloads = %d, want 1", cache, calls)
+		}
+
+		if _, err := f.Fetch(context.Background(), "U1234", srv.URL+"/html"); !errors.Is(err, ErrUnsupportedImageType) {
+			t.Errorf("Fetch(html) = %v", err)
+		}
+		if _, err := f.Fetch(context.Background(), "U1234", srv.URL+"/huge"); !errors.Is(err, ErrImageTooLarge) {
+			t.Errorf("Fetch(huge) = %v", err)
+		}
+	}
+
+	// Redirects are checked against the allowed hosts on every hop.
+	f := client.ProfileImageFetcher(ProfileImageOptions{AllowedHosts: []string{"127.0.0.1"}})
+	if img, err := f.Fetch(context.Background(), "U1234", srv.URL+"/moved"); err != nil || string(img.Data) != png {
+		t.Errorf("Fetch(moved) = %v", err)
+	}
+	if _, err := f.Fetch(context.Background(), "U1234", srv.URL+"/escape"); !errors.Is(err, ErrImageHostNotAllowed) {
+		t.Errorf("Fetch(escape) = %v, want ErrImageHostNotAllowed", err)
+	}
+
+	f = client.ProfileImageFetcher(ProfileImageOptions{})
+	for _, u := range []string{srv.URL + "/avatar", "http://profile.line-scdn.net/abc", "https://evil.example/line-scdn.net"} {
+		if _, err := f.Fetch(context.Background(), "U1234", u); !errors.Is(err, ErrImageHostNotAllowed) {
+			t.Errorf("Fetch(%q) = %v, want ErrImageHostNotAllowed", u, err)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package social
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Gender type
+type Gender string
+
+// Gender values
+const (
+	GenderUnspecified Gender = ""
+	GenderMale        Gender = "male"
+	GenderFemale      Gender = "female"
+	GenderOther       Gender = "other"
+)
+
+// ParseGender maps the gender claim to a Gender. Unknown values are GenderOther.
+func ParseGender(s string) Gender {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "":
+		return GenderUnspecified
+	case "male":
+		return GenderMale
+	case "female":
+		return GenderFemale
+	}
+	return GenderOther
+}
+
+// Birthdate is a birthdate claim. Year is 0 when the user did not share it;
+// Month and Day are 0 when only the year is known.
+type Birthdate struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// ParseBirthdate parses the OpenID Connect birthdate forms "YYYY-MM-DD",
+// "0000-MM-DD" (year withheld) and "YYYY" (year only).
+func ParseBirthdate(s string) (Birthdate, error) {
+	var b Birthdate
+	if s == "" {
+		return b, fmt.Errorf("empty birthdate")
+	}
+	parts := strings.Split(s, "-")
+	if len(parts) != 1 && len(parts) != 3 || len(parts[0]) != 4 {
+		return b, fmt.Errorf("invalid birthdate %q", s)
+	}
+	year, err := strconv.Atoi(parts[0])
+	if err != nil || year < 0 {
+		return b, fmt.Errorf("invalid birthdate %q", s)
+	}
+	b.Year = year
+	if len(parts) == 1 {
+		if year == 0 {
+			return b, fmt.Errorf("invalid birthdate %q", s)
+		}
+		return b, nil
+	}
+	month, err1 := strconv.Atoi(parts[1])
+	day, err2 := strconv.Atoi(parts[2])
+	if err1 != nil || err2 != nil || len(parts[1]) != 2 || len(parts[2]) != 2 {
+		return b, fmt.Errorf("invalid birthdate %q", s)
+	}
+	// Check the day against the month, using a leap year when the year is withheld.
+	checkYear := year
+	if checkYear == 0 {
+		checkYear = 2000
+	}
+	t := time.Date(checkYear, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if month < 1 || month > 12 || t.Month() != time.Month(month) || t.Day() != day {
+		return b, fmt.Errorf("invalid birthdate %q", s)
+	}
+	b.Month, b.Day = time.Month(month), day
+	return b, nil
+}
+
+// HasYear reports whether the year is known.
+func (b Birthdate) HasYear() bool {
+	return b.Year != 0
+}
+
+// HasDay reports whether the month and day are known.
+func (b Birthdate) HasDay() bool {
+	return b.Month != 0
+}
+
+// Time returns the birthdate as midnight UTC. ok is false unless the full
+// date is known.
+func (b Birthdate) Time() (t time.Time, ok bool) {
+	if !b.HasYear() || !b.HasDay() {
+		return time.Time{}, false
+	}
+	return time.Date(b.Year, b.Month, b.Day, 0, 0, 0, 0, time.UTC), true
+}
+
+// String returns the birthdate in the form it was parsed from.
+func (b Birthdate) String() string {
+	if !b.HasDay() {
+		return fmt.Sprintf("%04d", b.Year)
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", b.Year, b.Month, b.Day)
+}
+
+// NormalizePhoneNumber returns phone in E.164 form, e.g. "+819012345678".
+// Spaces, hyphens, dots and parentheses are removed and a leading "00" is
+// read as "+". Numbers in national form ("090-1234-5678") need
+// defaultCountryCode ("81"); its trunk prefix "0" is dropped.
+func NormalizePhoneNumber(phone, defaultCountryCode string) (string, error) {
+	var digits strings.Builder
+	for i, r := range strings.TrimSpace(phone) {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' && i == 0:
+			digits.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return "", fmt.Errorf("invalid phone number %q", phone)
+		}
+	}
+	s := digits.String()
+	switch {
+	case strings.HasPrefix(s, "+"):
+	case strings.HasPrefix(s, "00"):
+		s = "+" + s[2:]
+	case strings.HasPrefix(s, "0") && defaultCountryCode != "":
+		s = "+" + strings.TrimPrefix(defaultCountryCode, "+") + s[1:]
+	default:
+		return "", fmt.Errorf("phone number %q has no country code", phone)
+	}
+	// E.164 numbers have at most 15 digits and never start with 0.
+	if n := len(s) - 1; n < 8 || n > 15 || s[1] == '0' {
+		return "", fmt.Errorf("invalid phone number %q", phone)
+	}
+	return s, nil
+}
+
+// ParsedGender returns the gender claim as a Gender.
+func (p *LineProfilePlusPayload) ParsedGender() Gender {
+	return ParseGender(p.Gender)
+}
+
+// ParsedBirthdate returns the birthdate claim.
+func (p *LineProfilePlusPayload) ParsedBirthdate() (Birthdate, error) {
+	return ParseBirthdate(p.Birthdate)
+}
+
+// E164PhoneNumber returns the phone_number claim in E.164 form.
+func (p *LineProfilePlusPayload) E164PhoneNumber() (string, error) {
+	return NormalizePhoneNumber(p.PhoneNumber, "")
+}
+
+// ParsedGender returns the gender claim as a Gender.
+func (r *GetUserInfoResponse) ParsedGender() Gender {
+	return ParseGender(r.Gender)
+}
+
+// ParsedBirthdate returns the birthdate claim.
+func (r *GetUserInfoResponse) ParsedBirthdate() (Birthdate, error) {
+	return ParseBirthdate(r.Birthdate)
+}
+
+// E164PhoneNumber returns the phone_number claim in E.164 form.
+func (r *GetUserInfoResponse) E164PhoneNumber() (string, error) {
+	return NormalizePhoneNumber(r.PhoneNumber, "")
+}
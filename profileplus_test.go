@@ -0,0 +1,91 @@
+package social
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseBirthdate(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Birthdate
+		hasYear bool
+		wantErr bool
+	}{
+		{"1990-04-01", Birthdate{1990, time.April, 1}, true, false},
+		{"0000-04-01", Birthdate{0, time.April, 1}, false, false},
+		{"0000-02-29", Birthdate{0, time.February, 29}, false, false},
+		{"1990", Birthdate{Year: 1990}, true, false},
+		{"1990-02-29", Birthdate{}, false, true},
+		{"1990-13-01", Birthdate{}, false, true},
+		{"1990-4-1", Birthdate{}, false, true},
+		{"0000", Birthdate{}, false, true},
+		{"", Birthdate{}, false, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseBirthdate(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseBirthdate(%q) error = %v", tt.in, err)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if got != tt.want || got.HasYear() != tt.hasYear || got.String() != tt.in {
+			t.Errorf("ParseBirthdate(%q) = %+v (%s)", tt.in, got, got)
+		}
+	}
+}
+
+func TestNormalizePhoneNumber(t *testing.T) {
+	tests := []struct {
+		in, country, want string
+	}{
+		{"+81 90-1234-5678", "", "+819012345678"},
+		{"+1 (415) 555.2671", "", "+14155552671"},
+		{"0081 90 1234 5678", "", "+819012345678"},
+		{"090-1234-5678", "81", "+819012345678"},
+		{"090-1234-5678", "", ""},
+		{"+81 90-1234-5678 ext 1", "", ""},
+		{"+0 1234 5678", "", ""},
+		{"+81 9012 3456 7890 123", "", ""},
+	}
+	for _, tt := range tests {
+		got, err := NormalizePhoneNumber(tt.in, tt.country)
+		if got != tt.want || (err != nil) != (tt.want == "") {
+			t.Errorf("NormalizePhoneNumber(%q, %q) = %q, %v; want %q", tt.in, tt.country, got, err, tt.want)
+		}
+	}
+}
+
+func TestGetUserInfoProfilePlus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"sub":"U1234","given_name":"Taro","family_name":"Yamada","gender":"male",
+			"birthdate":"0000-04-01","phone_number":"+81 90-1234-5678",
+			"address":{"postal_code":"1000001","region":"Tokyo","country":"JP"}}`)
+	}))
+	defer srv.Close()
+	client, err := New("1234", "secret", WithEndpointBase(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := client.GetUserInfo("token").Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.GivenName != "Taro" || info.Address == nil || info.Address.Region != "Tokyo" {
+		t.Errorf("info = %+v", info)
+	}
+	if info.ParsedGender() != GenderMale {
+		t.Errorf("ParsedGender() = %q", info.ParsedGender())
+	}
+	if b, err := info.ParsedBirthdate(); err != nil || b.HasYear() || b.Month != time.April {
+		t.Errorf("ParsedBirthdate() = %+v, %v", b, err)
+	}
+	if phone, err := info.E164PhoneNumber(); err != nil || phone != "+819012345678" {
+		t.Errorf("E164PhoneNumber() = %q, %v", phone, err)
+	}
+}
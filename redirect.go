@@ -0,0 +1,207 @@
+package social
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	b64 "encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// RedirectPolicy is an allowlist of callback URLs. Entries without "*" must
+// match exactly. In pattern entries "*" stands for one whole host label,
+// e.g. "https://*.example.com/callback"; scheme, port and path must match
+// exactly and URLs with a query are rejected.
+//
+// The policy also lists the origins that signed return_to values may point
+// to. Relative paths such as "/account" are always allowed.
+type RedirectPolicy struct {
+	exact         map[string]bool
+	patterns      []*url.URL
+	returnOrigins map[string]bool
+}
+
+// NewRedirectPolicy returns a policy allowing the given callback URLs and patterns.
+func NewRedirectPolicy(allowed ...string) *RedirectPolicy {
+	p := &RedirectPolicy{
+		exact:         map[string]bool{},
+		returnOrigins: map[string]bool{},
+	}
+	for _, a := range allowed {
+		if strings.Contains(a, "*") {
+			// Unparsable patterns never match.
+			if u, err := url.Parse(a); err == nil {
+				p.patterns = append(p.patterns, u)
+			}
+		} else {
+			p.exact[a] = true
+		}
+	}
+	return p
+}
+
+// AllowReturnOrigins allows absolute return_to URLs on the given origins,
+// e.g. "https://www.example.com".
+func (p *RedirectPolicy) AllowReturnOrigins(origins ...string) *RedirectPolicy {
+	for _, o := range origins {
+		p.returnOrigins[strings.TrimSuffix(o, "/")] = true
+	}
+	return p
+}
+
+// Check returns ErrRedirectURINotAllowed unless redirectURL is allowed.
+func (p *RedirectPolicy) Check(redirectURL string) error {
+	if p.exact[redirectURL] {
+		return nil
+	}
+	u, err := url.Parse(redirectURL)
+	if err == nil && u.Fragment == "" && u.User == nil && u.RawQuery == "" && !u.ForceQuery {
+		for _, pattern := range p.patterns {
+			if matchRedirectPattern(pattern, u) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%w: %q", ErrRedirectURINotAllowed, redirectURL)
+}
+
+// checkReturnTo rejects return_to values that would redirect off-site.
+func (p *RedirectPolicy) checkReturnTo(returnTo string) error {
+	if isLocalPath(returnTo) {
+		return nil
+	}
+	u, err := url.Parse(returnTo)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.User != nil || p == nil {
+		return fmt.Errorf("%w: %q", ErrInvalidReturnTo, returnTo)
+	}
+	if !p.returnOrigins[u.Scheme+"://"+u.Host] {
+		return fmt.Errorf("%w: origin of %q is not allowed", ErrInvalidReturnTo, returnTo)
+	}
+	return nil
+}
+
+// isLocalPath reports whether s is a path on the current origin. "//host"
+// and "/\host" are treated as absolute by browsers and are rejected.
+func isLocalPath(s string) bool {
+	if !strings.HasPrefix(s, "/") || strings.HasPrefix(s, "//") || strings.HasPrefix(s, "/\\") {
+		return false
+	}
+	return !strings.ContainsAny(s, "\r\n\t")
+}
+
+// matchRedirectPattern matches u against pattern. Scheme, port and path
+// must be equal; a "*" label of the pattern host matches any one label.
+func matchRedirectPattern(pattern, u *url.URL) bool {
+	if u.Scheme != pattern.Scheme || u.Port() != pattern.Port() || u.EscapedPath() != pattern.EscapedPath() || u.Opaque != pattern.Opaque {
+		return false
+	}
+	want := strings.Split(pattern.Hostname(), ".")
+	got := strings.Split(u.Hostname(), ".")
+	if len(got) != len(want) {
+		return false
+	}
+	for i, label := range want {
+		if got[i] == "" || (label != "*" && !strings.EqualFold(label, got[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+// WithRedirectPolicy restricts the callback URLs the client builds
+// authorization URLs for and exchanges codes with.
+func WithRedirectPolicy(p *RedirectPolicy) ClientOption {
+	return func(client *Client) error {
+		client.redirectPolicy = p
+		return nil
+	}
+}
+
+func (client *Client) checkRedirectURL(redirectURL string) error {
+	if client.redirectPolicy == nil {
+		return nil
+	}
+	return client.redirectPolicy.Check(redirectURL)
+}
+
+// returnToClaims is the signed content of a return_to value.
+type returnToClaims struct {
+	ReturnTo  string `json:"r"`
+	ExpiresAt int64  `json:"e"`
+}
+
+// returnToContext separates return_to signatures from other uses of the channel secret.
+const returnToContext = "line-login-sdk-go/return_to\x00"
+
+// SignReturnTo returns a signed value carrying returnTo that expires after
+// ttl. Store it with the login state and pass it to VerifyReturnTo in the
+// callback. returnTo must be a local path or on an origin allowed by the
+// redirect policy.
+func (client *Client) SignReturnTo(returnTo string, ttl time.Duration) (string, error) {
+	if err := client.redirectPolicy.checkReturnTo(returnTo); err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(returnToClaims{ReturnTo: returnTo, ExpiresAt: time.Now().Add(ttl).Unix()})
+	if err != nil {
+		return "", err
+	}
+	secret, err := client.currentSecret()
+	if err != nil {
+		return "", err
+	}
+	encoded := b64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + b64.RawURLEncoding.EncodeToString(signReturnTo(secret, encoded)), nil
+}
+
+// VerifyReturnTo checks a value from SignReturnTo and returns the URL it
+// carries. It returns ErrInvalidReturnTo for tampered or disallowed values
+// and ErrReturnToExpired after the TTL.
+func (client *Client) VerifyReturnTo(signed string) (string, error) {
+	encoded, sig, ok := strings.Cut(signed, ".")
+	if !ok {
+		return "", ErrInvalidReturnTo
+	}
+	mac, err := b64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return "", ErrInvalidReturnTo
+	}
+	secrets, err := client.verificationSecrets()
+	if err != nil {
+		return "", err
+	}
+	valid := false
+	for _, secret := range secrets {
+		if hmac.Equal(mac, signReturnTo(secret, encoded)) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return "", ErrInvalidReturnTo
+	}
+	payload, err := b64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrInvalidReturnTo
+	}
+	var claims returnToClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", ErrInvalidReturnTo
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return "", ErrReturnToExpired
+	}
+	if err := client.redirectPolicy.checkReturnTo(claims.ReturnTo); err != nil {
+		return "", err
+	}
+	return claims.ReturnTo, nil
+}
+
+func signReturnTo(secret, encoded string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(returnToContext))
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,149 @@
+package social
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestRedirectPolicy(t *testing.T) {
+	p := NewRedirectPolicy("https://example.com/callback", "https://*.example.com/callback")
+	for _, u := range []string{
+		"https://example.com/callback",
+		"https://app.example.com/callback",
+	} {
+		if err := p.Check(u); err != nil {
+			t.Errorf("Check(%q) = %v", u, err)
+		}
+	}
+	for _, u := range []string{
+		"https://example.com/callback2",
+		"https://evil.com/x.example.com/callback",
+		"https://evil.com?.example.com/callback",
+		"https://app.example.com/callback?x=1",
+		"https://app.example.com:8443/callback",
+		"https://a.b.example.com/callback",
+		"https://.example.com/callback",
+		"https://app.example.com/callback#frag",
+		"http://example.com/callback",
+	} {
+		if err := p.Check(u); !errors.Is(err, ErrRedirectURINotAllowed) {
+			t.Errorf("Check(%q) = %v, want ErrRedirectURINotAllowed", u, err)
+		}
+	}
+
+	client, _ := New("1234", "secret", WithRedirectPolicy(p))
+	if _, err := client.GetWebLoinURL("https://evil.com/callback", "state", "profile", AuthRequestOptions{}); !errors.Is(err, ErrRedirectURINotAllowed) {
+		t.Errorf("GetWebLoinURL err = %v", err)
+	}
+	if _, err := client.GetAccessToken("https://evil.com/callback", "code").Do(); !errors.Is(err, ErrRedirectURINotAllowed) {
+		t.Errorf("GetAccessToken err = %v", err)
+	}
+}
+
+func TestReturnTo(t *testing.T) {
+	client, _ := New("1234", "secret", WithRedirectPolicy(NewRedirectPolicy().AllowReturnOrigins("https://www.example.com")))
+
+	for _, returnTo := range []string{"/account?tab=1", "https://www.example.com/cart"} {
+		signed, err := client.SignReturnTo(returnTo, time.Minute)
+		if err != nil {
+			t.Fatalf("SignReturnTo(%q) = %v", returnTo, err)
+		}
+		got, err := client.VerifyReturnTo(signed)
+		if err != nil || got != returnTo {
+			t.Errorf("VerifyReturnTo = %q, %v, want %q", got, err, returnTo)
+		}
+	}
+
+	for _, returnTo := range []string{"//evil.com", "/\\evil.com", "https://evil.com/", "javascript:alert(1)"} {
+		if _, err := client.SignReturnTo(returnTo, time.Minute); !errors.Is(err, ErrInvalidReturnTo) {
+			t.Errorf("SignReturnTo(%q) = %v, want ErrInvalidReturnTo", returnTo, err)
+		}
+	}
+
+	signed, _ := client.SignReturnTo("/account", time.Minute)
+	other, _ := New("1234", "other-secret")
+	if _, err := other.VerifyReturnTo(signed); !errors.Is(err, ErrInvalidReturnTo) {
+		t.Errorf("VerifyReturnTo with another secret = %v", err)
+	}
+	expired, _ := client.SignReturnTo("/account", -time.Second)
+	if _, err := client.VerifyReturnTo(expired); !errors.Is(err, ErrReturnToExpired) {
+		t.Errorf("VerifyReturnTo expired = %v", err)
+	}
+}
+
+func TestBeginAndCompleteLogin(t *testing.T) {
+	var nonce string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("code") != "auth-code" || r.Form.Get("code_verifier") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(TokenResponse{
+			AccessToken: "access",
+			IDToken:     testIDToken(t, "secret", map[string]any{"iss": IDTokenIssuer, "aud": "1234", "sub": "U1", "nonce": nonce, "exp": time.Now().Add(time.Hour).Unix()}),
+		})
+	}))
+	defer srv.Close()
+
+	callback := "https://example.com/callback"
+	client, _ := New("1234", "secret", WithEndpointBase(srv.URL), WithRedirectPolicy(NewRedirectPolicy(callback)))
+	store := NewMemoryStateStore()
+	ctx := context.Background()
+
+	authURL, err := client.BeginLogin(ctx, store, LoginRequest{Binding: "browser-1", RedirectURI: callback, Scope: "openid", ReturnTo: "/cart", PKCE: true, Options: AuthRequestOptions{BotPrompt: BotPromptNormal}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(authURL)
+	nonce = u.Query().Get("nonce")
+	state := u.Query().Get("state")
+
+	res, err := client.CompleteLogin(ctx, store, "browser-1", url.Values{"state": {state}, "code": {"auth-code"}, "friendship_status_changed": {"true"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ReturnTo != "/cart" || res.Payload.Sub != "U1" || res.State.BotPrompt != BotPromptNormal ||
+		res.FriendshipStatusChanged == nil || !*res.FriendshipStatusChanged {
+		t.Errorf("result = %+v", res)
+	}
+
+	// The state is single use.
+	if _, err := client.CompleteLogin(ctx, store, "browser-1", url.Values{"state": {state}, "code": {"auth-code"}}); !errors.Is(err, ErrStateNotFound) {
+		t.Errorf("second CompleteLogin err = %v", err)
+	}
+
+	// A state started in another browser is rejected.
+	authURL, _ = client.BeginLogin(ctx, store, LoginRequest{Binding: "browser-1", RedirectURI: callback, Scope: "openid"})
+	u, _ = url.Parse(authURL)
+	if _, err := client.CompleteLogin(ctx, store, "browser-2", url.Values{"state": {u.Query().Get("state")}, "code": {"auth-code"}}); !errors.Is(err, ErrStateBindingMismatch) {
+		t.Errorf("CompleteLogin from another browser err = %v", err)
+	}
+	if _, err := client.BeginLogin(ctx, store, LoginRequest{RedirectURI: callback}); err == nil {
+		t.Errorf("BeginLogin without binding succeeded")
+	}
+}
+
+func TestLoginBinding(t *testing.T) {
+	rec := httptest.NewRecorder()
+	binding, err := LoginBinding(rec, httptest.NewRequest("GET", "/login", nil))
+	if err != nil || binding == "" {
+		t.Fatalf("LoginBinding = %q, %v", binding, err)
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != LoginBindingCookie || !cookies[0].HttpOnly {
+		t.Fatalf("cookies = %v", cookies)
+	}
+	req := httptest.NewRequest("GET", "/callback", nil)
+	req.AddCookie(cookies[0])
+	rec = httptest.NewRecorder()
+	if again, _ := LoginBinding(rec, req); again != binding || len(rec.Result().Cookies()) != 0 {
+		t.Errorf("LoginBinding with cookie = %q", again)
+	}
+}
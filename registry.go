@@ -0,0 +1,165 @@
+package social
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ChannelConfig describes one LINE Login channel in a Registry.
+type ChannelConfig struct {
+	ChannelID     string
+	ChannelSecret string
+	// SecretProvider replaces ChannelSecret when set.
+	SecretProvider SecretProvider
+	// TenantKeys route LoginURL and ClientForTenant to this channel, e.g.
+	// "brand-a/jp".
+	TenantKeys []string
+	// RedirectPolicy restricts this channel's callback URLs.
+	RedirectPolicy *RedirectPolicy
+	// Options are applied after the registry's shared options.
+	Options []ClientOption
+}
+
+// Registry holds the clients of several LINE Login channels served by one
+// application and routes tokens to the channel that issued them.
+type Registry struct {
+	shared []ClientOption
+
+	mu        sync.RWMutex
+	order     []*Client
+	byChannel map[string]*Client
+	byTenant  map[string]*Client
+}
+
+// NewRegistry returns an empty registry. The shared options, such as
+// WithHTTPClient, WithRetryPolicy and WithMetrics, apply to every channel.
+func NewRegistry(shared ...ClientOption) *Registry {
+	return &Registry{
+		shared:    shared,
+		byChannel: map[string]*Client{},
+		byTenant:  map[string]*Client{},
+	}
+}
+
+// Register creates the client for a channel and adds it to the registry.
+func (r *Registry) Register(cfg ChannelConfig) (*Client, error) {
+	options := append([]ClientOption{}, r.shared...)
+	if cfg.SecretProvider != nil {
+		options = append(options, WithSecretProvider(cfg.SecretProvider))
+	}
+	if cfg.RedirectPolicy != nil {
+		options = append(options, WithRedirectPolicy(cfg.RedirectPolicy))
+	}
+	options = append(options, cfg.Options...)
+	client, err := New(cfg.ChannelID, cfg.ChannelSecret, options...)
+	if err != nil {
+		return nil, fmt.Errorf("channel %s: %w", cfg.ChannelID, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.byChannel[cfg.ChannelID]; ok {
+		return nil, fmt.Errorf("channel %s is already registered", cfg.ChannelID)
+	}
+	for _, key := range cfg.TenantKeys {
+		if other, ok := r.byTenant[key]; ok {
+			return nil, fmt.Errorf("tenant %q is already registered for channel %s", key, other.channelID)
+		}
+	}
+	r.order = append(r.order, client)
+	r.byChannel[cfg.ChannelID] = client
+	for _, key := range cfg.TenantKeys {
+		r.byTenant[key] = client
+	}
+	return client, nil
+}
+
+// Channels returns the registered channel IDs in registration order.
+func (r *Registry) Channels() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ids := make([]string, len(r.order))
+	for i, c := range r.order {
+		ids[i] = c.channelID
+	}
+	return ids
+}
+
+// Client returns the client of a channel, or ErrUnknownChannel.
+func (r *Registry) Client(channelID string) (*Client, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if c, ok := r.byChannel[channelID]; ok {
+		return c, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownChannel, channelID)
+}
+
+// ClientForTenant returns the client registered for a tenant key, or ErrUnknownChannel.
+func (r *Registry) ClientForTenant(key string) (*Client, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if c, ok := r.byTenant[key]; ok {
+		return c, nil
+	}
+	return nil, fmt.Errorf("%w: no channel for tenant %q", ErrUnknownChannel, key)
+}
+
+// ClientForIDToken returns the client of the channel named by the ID token's
+// aud claim. The token is only decoded, not verified.
+func (r *Registry) ClientForIDToken(idToken string) (*Client, error) {
+	aud, err := idTokenAudience(idToken)
+	if err != nil {
+		return nil, err
+	}
+	return r.Client(aud)
+}
+
+// ClientForTokenVerify returns the client of the channel an access token
+// was issued for, from the client_id of a TokenVerify result.
+func (r *Registry) ClientForTokenVerify(res *TokenVerifyResponse) (*Client, error) {
+	return r.Client(res.ClientID)
+}
+
+// VerifyIDToken verifies an ID token with the channel named by its aud claim.
+func (r *Registry) VerifyIDToken(ctx context.Context, idToken string, options VerifyIDTokenRequestOptions) (*VerifyIDTokenResponse, error) {
+	client, err := r.ClientForIDToken(idToken)
+	if err != nil {
+		return nil, err
+	}
+	return client.VerifyIDToken(idToken, options).WithContext(ctx).Do()
+}
+
+// VerifyAccessToken verifies an access token and returns it together with
+// the client of the channel it was issued for. Tokens of unregistered
+// channels fail with ErrUnknownChannel.
+func (r *Registry) VerifyAccessToken(ctx context.Context, accessToken string) (*Client, *TokenVerifyResponse, error) {
+	r.mu.RLock()
+	if len(r.order) == 0 {
+		r.mu.RUnlock()
+		return nil, nil, fmt.Errorf("%w: registry is empty", ErrUnknownChannel)
+	}
+	// The verify endpoint needs no channel credentials; any client will do.
+	verifier := r.order[0]
+	r.mu.RUnlock()
+
+	res, err := verifier.TokenVerify(accessToken).WithContext(ctx).Do()
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := r.ClientForTokenVerify(res)
+	if err != nil {
+		return nil, nil, err
+	}
+	return client, res, nil
+}
+
+// LoginURL builds the authorization URL for a tenant's channel.
+func (r *Registry) LoginURL(tenant, redirectURL, state, scope string, options AuthRequestOptions) (string, error) {
+	client, err := r.ClientForTenant(tenant)
+	if err != nil {
+		return "", err
+	}
+	return client.GetWebLoinURL(redirectURL, state, scope, options)
+}
@@ -0,0 +1,162 @@
+package social
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testMetrics struct {
+	mu       sync.Mutex
+	statuses []int
+	channels []string
+}
+
+func (m *testMetrics) ObserveRequest(channelID, endpoint string, statusCode int, err error, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.statuses = append(m.statuses, statusCode)
+	m.channels = append(m.channels, channelID)
+}
+
+func TestRegistryRouting(t *testing.T) {
+	r := NewRegistry()
+	if _, err := r.Register(ChannelConfig{ChannelID: "1234", ChannelSecret: "secret-a", TenantKeys: []string{"brand-a/jp"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Register(ChannelConfig{ChannelID: "5678", ChannelSecret: "secret-b", TenantKeys: []string{"brand-b/tw"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Register(ChannelConfig{ChannelID: "1234", ChannelSecret: "other"}); err == nil {
+		t.Errorf("duplicate channel registered")
+	}
+	if _, err := r.Register(ChannelConfig{ChannelID: "9999", ChannelSecret: "other", TenantKeys: []string{"brand-a/jp"}}); err == nil {
+		t.Errorf("duplicate tenant registered")
+	}
+	if got := strings.Join(r.Channels(), ","); got != "1234,5678" {
+		t.Errorf("Channels() = %s", got)
+	}
+
+	claims := testClaims(time.Now())
+	claims["aud"] = "5678"
+	client, err := r.ClientForIDToken(testIDToken(t, "secret-b", claims))
+	if err != nil || client.channelID != "5678" {
+		t.Errorf("ClientForIDToken = %v, %v", client, err)
+	}
+	claims["aud"] = "0000"
+	if _, err := r.ClientForIDToken(testIDToken(t, "secret-b", claims)); !errors.Is(err, ErrUnknownChannel) {
+		t.Errorf("ClientForIDToken(unknown aud) = %v, want ErrUnknownChannel", err)
+	}
+
+	u, err := r.LoginURL("brand-b/tw", "https://example.com/callback", "state", "openid", AuthRequestOptions{})
+	if err != nil || !strings.Contains(u, "client_id=5678") {
+		t.Errorf("LoginURL = %s, %v", u, err)
+	}
+	if _, err := r.LoginURL("brand-c", "https://example.com/callback", "state", "openid", AuthRequestOptions{}); !errors.Is(err, ErrUnknownChannel) {
+		t.Errorf("LoginURL(unknown tenant) = %v, want ErrUnknownChannel", err)
+	}
+}
+
+func TestRegistrySharedRetryAndMetrics(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"scope":"profile","client_id":"5678","expires_in":3600}`)
+	}))
+	defer srv.Close()
+
+	metrics := &testMetrics{}
+	r := NewRegistry(
+		WithEndpointBase(srv.URL),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}),
+		WithMetrics(metrics),
+	)
+	for _, id := range []string{"1234", "5678"} {
+		if _, err := r.Register(ChannelConfig{ChannelID: id, ChannelSecret: "secret"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	client, res, err := r.VerifyAccessToken(context.Background(), "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.channelID != "5678" || res.Scope != "profile" {
+		t.Errorf("VerifyAccessToken = %s, %+v", client.channelID, res)
+	}
+	if calls != 2 {
+		t.Errorf("server calls = %d, want 2", calls)
+	}
+	if fmt.Sprint(metrics.statuses) != "[503 200]" {
+		t.Errorf("observed statuses = %v", metrics.statuses)
+	}
+}
+
+func TestRetryStopsOnClientError(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":"invalid_request"}`)
+	}))
+	defer srv.Close()
+
+	client, err := New("1234", "secret", WithEndpointBase(srv.URL), WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.TokenVerify("token").Do(); err == nil {
+		t.Errorf("TokenVerify succeeded on 400")
+	}
+	if calls != 1 {
+		t.Errorf("server calls = %d, want 1", calls)
+	}
+}
+
+func TestRetryOnlyGETByDefault(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	client, err := New("1234", "secret", WithEndpointBase(srv.URL), WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.RefreshToken("refresh").Do(); err == nil || calls != 1 {
+		t.Errorf("RefreshToken = %v, server calls = %d, want 1", err, calls)
+	}
+	calls = 0
+	if _, err := client.RevokeToken("token").WithContext(AllowRetry(context.Background())).Do(); err == nil || calls != 3 {
+		t.Errorf("RevokeToken with AllowRetry = %v, server calls = %d, want 3", err, calls)
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	if d := p.delay(1, nil); d != 100*time.Millisecond {
+		t.Errorf("delay(1) = %v", d)
+	}
+	if d := p.delay(3, nil); d != 400*time.Millisecond {
+		t.Errorf("delay(3) = %v", d)
+	}
+	if d := p.delay(10, nil); d != time.Second {
+		t.Errorf("delay(10) = %v", d)
+	}
+	res := &http.Response{Header: http.Header{"Retry-After": []string{"0"}}}
+	if d := p.delay(3, res); d != 0 {
+		t.Errorf("delay with Retry-After: 0 = %v", d)
+	}
+}
@@ -0,0 +1,206 @@
+package social
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Replay kinds
+const (
+	ReplayKindCode  = "code"
+	ReplayKindNonce = "nonce"
+)
+
+// AuthorizationCodeTTL is the validity of an authorization code.
+const AuthorizationCodeTTL = 10 * time.Minute
+
+// IDTokenTTL is the validity of an ID token issued by LINE Login.
+const IDTokenTTL = time.Hour
+
+// ReplayGuard records one-time values such as authorization codes and ID
+// token nonces so that a second use can be rejected.
+type ReplayGuard interface {
+	// Use records value of the given kind as used for ttl. It returns a
+	// *ReplayError when the value was already used and has not expired.
+	Use(ctx context.Context, kind, value string, ttl time.Duration) error
+}
+
+// ReplayError is returned when a one-time value is used twice. It matches
+// ErrReplayDetected with errors.Is.
+type ReplayError struct {
+	Kind string
+}
+
+// Error method
+func (e *ReplayError) Error() string {
+	return fmt.Sprintf("%s: %s already used", ErrReplayDetected, e.Kind)
+}
+
+// Is method
+func (e *ReplayError) Is(target error) bool {
+	return target == ErrReplayDetected
+}
+
+// WithReplayGuard rejects reused authorization codes in GetAccessToken and
+// GetAccessTokenPKCE and expected nonces in VerifyIDToken before any network
+// call, and reused nonces in ValidateIDToken.
+func WithReplayGuard(g ReplayGuard) ClientOption {
+	return func(client *Client) error {
+		client.replayGuard = g
+		return nil
+	}
+}
+
+func (client *Client) useOnce(ctx context.Context, kind, value string, ttl time.Duration) error {
+	if client.replayGuard == nil {
+		return nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return client.replayGuard.Use(ctx, kind, value, ttl)
+}
+
+// replayKey hashes the value so stores never hold usable codes.
+func replayKey(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// MemoryReplayGuard is a ReplayGuard for a single process.
+type MemoryReplayGuard struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+	now     func() time.Time
+}
+
+// NewMemoryReplayGuard returns an empty MemoryReplayGuard.
+func NewMemoryReplayGuard() *MemoryReplayGuard {
+	return &MemoryReplayGuard{
+		entries: map[string]time.Time{},
+		now:     time.Now,
+	}
+}
+
+// Use method
+func (g *MemoryReplayGuard) Use(ctx context.Context, kind, value string, ttl time.Duration) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	key := kind + ":" + replayKey(value)
+	if exp, ok := g.entries[key]; ok && now.Before(exp) {
+		return &ReplayError{Kind: kind}
+	}
+	for k, exp := range g.entries {
+		if !now.Before(exp) {
+			delete(g.entries, k)
+		}
+	}
+	g.entries[key] = now.Add(ttl)
+	return nil
+}
+
+// SQLReplayGuard is a ReplayGuard backed by a database/sql table, so that
+// several processes share the record of used values. Create the table with
+// CreateTable or with the statement it runs.
+type SQLReplayGuard struct {
+	db          *sql.DB
+	table       string
+	placeholder func(n int) string
+	now         func() time.Time
+}
+
+// SQLReplayGuardOption type
+type SQLReplayGuardOption func(*SQLReplayGuard)
+
+// WithReplayTable sets the table name, optionally qualified by a schema.
+// Default "line_login_replay".
+func WithReplayTable(table string) SQLReplayGuardOption {
+	return func(g *SQLReplayGuard) {
+		g.table = table
+	}
+}
+
+// WithDollarPlaceholders uses $1, $2, ... placeholders as PostgreSQL
+// drivers require. The default is "?".
+func WithDollarPlaceholders() SQLReplayGuardOption {
+	return func(g *SQLReplayGuard) {
+		g.placeholder = func(n int) string { return fmt.Sprintf("$%d", n) }
+	}
+}
+
+// sqlIdentifier matches the table names NewSQLReplayGuard accepts. They are
+// written into the statements unquoted.
+var sqlIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// NewSQLReplayGuard returns a SQLReplayGuard using db. It fails when the
+// table name is not a plain SQL identifier.
+func NewSQLReplayGuard(db *sql.DB, options ...SQLReplayGuardOption) (*SQLReplayGuard, error) {
+	g := &SQLReplayGuard{
+		db:          db,
+		table:       "line_login_replay",
+		placeholder: func(int) string { return "?" },
+		now:         time.Now,
+	}
+	for _, option := range options {
+		option(g)
+	}
+	if !sqlIdentifier.MatchString(g.table) {
+		return nil, fmt.Errorf("invalid replay table name %q", g.table)
+	}
+	return g, nil
+}
+
+// CreateTable creates the replay table if it does not exist.
+func (g *SQLReplayGuard) CreateTable(ctx context.Context) error {
+	_, err := g.db.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	kind VARCHAR(16) NOT NULL,
+	value_hash CHAR(64) NOT NULL,
+	expires_at BIGINT NOT NULL,
+	PRIMARY KEY (kind, value_hash)
+)`, g.table))
+	return err
+}
+
+// Use method
+func (g *SQLReplayGuard) Use(ctx context.Context, kind, value string, ttl time.Duration) error {
+	key := replayKey(value)
+	now := g.now()
+	p1, p2, p3 := g.placeholder(1), g.placeholder(2), g.placeholder(3)
+
+	// Drop an expired record of the same value so the insert can succeed.
+	if _, err := g.db.ExecContext(ctx,
+		fmt.Sprintf("DELETE FROM %s WHERE kind = %s AND value_hash = %s AND expires_at <= %s", g.table, p1, p2, p3),
+		kind, key, now.Unix()); err != nil {
+		return err
+	}
+	_, err := g.db.ExecContext(ctx,
+		fmt.Sprintf("INSERT INTO %s (kind, value_hash, expires_at) VALUES (%s, %s, %s)", g.table, p1, p2, p3),
+		kind, key, now.Add(ttl).Unix())
+	if err == nil {
+		return nil
+	}
+	// The insert fails on the primary key when the value is in use. Other
+	// failures are reported as they are.
+	var n int
+	if qerr := g.db.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE kind = %s AND value_hash = %s", g.table, p1, p2),
+		kind, key).Scan(&n); qerr == nil && n > 0 {
+		return &ReplayError{Kind: kind}
+	}
+	return err
+}
+
+// Purge deletes expired records.
+func (g *SQLReplayGuard) Purge(ctx context.Context) error {
+	_, err := g.db.ExecContext(ctx,
+		fmt.Sprintf("DELETE FROM %s WHERE expires_at <= %s", g.table, g.placeholder(1)),
+		g.now().Unix())
+	return err
+}
@@ -0,0 +1,269 @@
+package social
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryReplayGuard(t *testing.T) {
+	now := time.Now()
+	g := NewMemoryReplayGuard()
+	g.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if err := g.Use(ctx, ReplayKindCode, "abc", time.Minute); err != nil {
+		t.Fatalf("first Use = %v", err)
+	}
+	err := g.Use(ctx, ReplayKindCode, "abc", time.Minute)
+	var replayErr *ReplayError
+	if !errors.As(err, &replayErr) || !errors.Is(err, ErrReplayDetected) || replayErr.Kind != ReplayKindCode {
+		t.Errorf("second Use = %v, want *ReplayError", err)
+	}
+	// The same value of another kind is independent.
+	if err := g.Use(ctx, ReplayKindNonce, "abc", time.Minute); err != nil {
+		t.Errorf("Use nonce = %v", err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if err := g.Use(ctx, ReplayKindCode, "abc", time.Minute); err != nil {
+		t.Errorf("Use after expiry = %v", err)
+	}
+}
+
+func TestClientRejectsReplayedCode(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		fmt.Fprint(w, `{"access_token":"access"}`)
+	}))
+	defer srv.Close()
+
+	client, _ := New("1234", "secret", WithEndpointBase(srv.URL), WithReplayGuard(NewMemoryReplayGuard()))
+	if _, err := client.GetAccessToken("https://example.com/cb", "code-1").Do(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetAccessTokenPKCE("https://example.com/cb", "code-1", "verifier").Do(); !errors.Is(err, ErrReplayDetected) {
+		t.Errorf("replayed code err = %v", err)
+	}
+	if hits != 1 {
+		t.Errorf("server hits = %d, want 1", hits)
+	}
+}
+
+type flakySecret struct{ failures int }
+
+func (f *flakySecret) Secrets() (string, []string, error) {
+	if f.failures > 0 {
+		f.failures--
+		return "", nil, errors.New("secret store unavailable")
+	}
+	return "secret", nil, nil
+}
+
+func TestSecretErrorKeepsCodeUsable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"access_token":"access"}`)
+	}))
+	defer srv.Close()
+
+	client, err := New("1234", "", WithSecretProvider(&flakySecret{failures: 1}), WithEndpointBase(srv.URL), WithReplayGuard(NewMemoryReplayGuard()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetAccessToken("https://example.com/cb", "code-1").Do(); err == nil {
+		t.Fatal("GetAccessToken succeeded without a secret")
+	}
+	if _, err := client.GetAccessToken("https://example.com/cb", "code-1").Do(); err != nil {
+		t.Errorf("GetAccessToken after the secret recovered = %v", err)
+	}
+}
+
+func TestValidateIDTokenRejectsReplayedNonce(t *testing.T) {
+	client, _ := New("1234", "secret", WithReplayGuard(NewMemoryReplayGuard()))
+	token := testIDToken(t, "secret", testClaims(time.Now()))
+	if _, err := client.ValidateIDToken(token, "nonce-1").Do(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.ValidateIDToken(token, "nonce-1").Do(); !errors.Is(err, ErrReplayDetected) {
+		t.Errorf("replayed nonce err = %v", err)
+	}
+}
+
+func TestVerifyIDTokenRejectsReplayedNonce(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		fmt.Fprintf(w, `{"sub":"U1234","aud":"1234","exp":%d,"nonce":"nonce-1"}`, time.Now().Add(time.Hour).Unix())
+	}))
+	defer srv.Close()
+
+	client, _ := New("1234", "secret", WithEndpointBase(srv.URL), WithReplayGuard(NewMemoryReplayGuard()))
+	options := VerifyIDTokenRequestOptions{Nonce: "nonce-1"}
+	if _, err := client.VerifyIDToken("id-token", options).Do(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.VerifyIDToken("id-token", options).Do(); !errors.Is(err, ErrReplayDetected) {
+		t.Errorf("replayed nonce err = %v", err)
+	}
+	if hits != 1 {
+		t.Errorf("server hits = %d, want 1", hits)
+	}
+	// Without an expected nonce nothing is recorded.
+	for i := 0; i < 2; i++ {
+		if _, err := client.VerifyIDToken("id-token", VerifyIDTokenRequestOptions{}).Do(); err != nil {
+			t.Errorf("VerifyIDToken without nonce = %v", err)
+		}
+	}
+}
+
+// replayDriver is a database/sql driver understanding just the statements of
+// SQLReplayGuard. Rows are keyed by kind and value hash.
+type replayDriver struct {
+	mu         sync.Mutex
+	rows       map[[2]string]int64
+	statements []string
+	insertErr  error
+}
+
+func (d *replayDriver) Open(string) (driver.Conn, error) { return replayConn{d}, nil }
+
+type replayConn struct{ d *replayDriver }
+
+func (c replayConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c replayConn) Close() error                        { return nil }
+func (c replayConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c replayConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	d := c.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.statements = append(d.statements, query)
+	switch {
+	case strings.HasPrefix(query, "CREATE TABLE"):
+	case strings.HasPrefix(query, "INSERT"):
+		if d.insertErr != nil {
+			return nil, d.insertErr
+		}
+		key := [2]string{args[0].Value.(string), args[1].Value.(string)}
+		if _, ok := d.rows[key]; ok {
+			return nil, errors.New("UNIQUE constraint failed")
+		}
+		d.rows[key] = args[2].Value.(int64)
+	case strings.HasPrefix(query, "DELETE") && len(args) == 3:
+		key := [2]string{args[0].Value.(string), args[1].Value.(string)}
+		if expires, ok := d.rows[key]; ok && expires <= args[2].Value.(int64) {
+			delete(d.rows, key)
+		}
+	case strings.HasPrefix(query, "DELETE"):
+		for key, expires := range d.rows {
+			if expires <= args[0].Value.(int64) {
+				delete(d.rows, key)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unexpected statement %q", query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c replayConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	d := c.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.statements = append(d.statements, query)
+	var n int64
+	if _, ok := d.rows[[2]string{args[0].Value.(string), args[1].Value.(string)}]; ok {
+		n = 1
+	}
+	return &countRows{n: n}, nil
+}
+
+type countRows struct {
+	n    int64
+	done bool
+}
+
+func (r *countRows) Columns() []string { return []string{"count"} }
+func (r *countRows) Close() error      { return nil }
+
+func (r *countRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.n
+	return nil
+}
+
+func TestSQLReplayGuard(t *testing.T) {
+	d := &replayDriver{rows: map[[2]string]int64{}}
+	sql.Register("replay-test", d)
+	db, err := sql.Open("replay-test", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	g, err := NewSQLReplayGuard(db, WithReplayTable("auth.replay"), WithDollarPlaceholders())
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	g.now = func() time.Time { return now }
+	ctx := context.Background()
+	if err := g.CreateTable(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := g.Use(ctx, ReplayKindCode, "abc", time.Minute); err != nil {
+		t.Fatalf("first Use = %v", err)
+	}
+	if d.rows[[2]string{ReplayKindCode, replayKey("abc")}] != now.Add(time.Minute).Unix() {
+		t.Errorf("rows = %v", d.rows)
+	}
+	err = g.Use(ctx, ReplayKindCode, "abc", time.Minute)
+	var replayErr *ReplayError
+	if !errors.As(err, &replayErr) || replayErr.Kind != ReplayKindCode {
+		t.Errorf("second Use = %v, want *ReplayError", err)
+	}
+	if err := g.Use(ctx, ReplayKindNonce, "abc", time.Minute); err != nil {
+		t.Errorf("Use nonce = %v", err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if err := g.Use(ctx, ReplayKindCode, "abc", time.Minute); err != nil {
+		t.Errorf("Use after expiry = %v", err)
+	}
+	now = now.Add(2 * time.Minute)
+	if err := g.Purge(ctx); err != nil || len(d.rows) != 0 {
+		t.Errorf("Purge = %v, rows = %v", err, d.rows)
+	}
+
+	// Failures other than a duplicate are reported as they are.
+	d.insertErr = errors.New("disk full")
+	if err := g.Use(ctx, ReplayKindCode, "def", time.Minute); err != d.insertErr {
+		t.Errorf("Use with failing insert = %v", err)
+	}
+
+	for _, query := range d.statements {
+		if !strings.Contains(query, "auth.replay") || strings.Contains(query, "?") {
+			t.Errorf("statement %q", query)
+		}
+	}
+
+	for _, table := range []string{"", "replay; DROP TABLE users", "1replay", "a.b.c", `"replay"`} {
+		if _, err := NewSQLReplayGuard(db, WithReplayTable(table)); err == nil {
+			t.Errorf("NewSQLReplayGuard(%q) succeeded", table)
+		}
+	}
+}
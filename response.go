@@ -22,6 +22,10 @@ type errorResponseDetail struct {
 type ErrorResponse struct {
 	Message string                `json:"message"`
 	Details []errorResponseDetail `json:"details"`
+
+	// Error and ErrorDescription are set by the OAuth endpoints.
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
 }
 
 // UserProfileResponse type
@@ -146,6 +150,18 @@ type GetUserInfoResponse struct {
 
 	// Picture: User's profile image URL. Only included if the profile scope was specified.
 	Picture string `json:"picture,omitempty"`
+
+	// LINE Profile+ claims. Each is only included if its scope was specified.
+	// https://developers.line.biz/en/docs/partner-docs/line-profile-plus/
+	GivenName               string   `json:"given_name,omitempty"`
+	GivenNamePronunciation  string   `json:"given_name_pronunciation,omitempty"`
+	MiddleName              string   `json:"middle_name,omitempty"`
+	FamilyName              string   `json:"family_name,omitempty"`
+	FamilyNamePronunciation string   `json:"family_name_pronunciation,omitempty"`
+	Gender                  string   `json:"gender,omitempty"`
+	Birthdate               string   `json:"birthdate,omitempty"`
+	PhoneNumber             string   `json:"phone_number,omitempty"`
+	Address                 *Address `json:"address,omitempty"`
 }
 
 // TokenResponse type
@@ -240,12 +256,14 @@ func checkResponse(res *http.Response) error {
 		result := ErrorResponse{}
 		if err := decoder.Decode(&result); err != nil {
 			return &APIError{
-				Code: res.StatusCode,
+				Code:   res.StatusCode,
+				Header: res.Header,
 			}
 		}
 		return &APIError{
 			Code:     res.StatusCode,
 			Response: &result,
+			Header:   res.Header,
 		}
 	}
 	return nil
@@ -257,12 +275,14 @@ func checkResponseNoContent(res *http.Response) error {
 		result := ErrorResponse{}
 		if err := decoder.Decode(&result); err != nil {
 			return &APIError{
-				Code: res.StatusCode,
+				Code:   res.StatusCode,
+				Header: res.Header,
 			}
 		}
 		return &APIError{
 			Code:     res.StatusCode,
 			Response: &result,
+			Header:   res.Header,
 		}
 	}
 	return nil
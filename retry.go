@@ -0,0 +1,126 @@
+package social
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy retries requests that failed with a network error, 429 Too
+// Many Requests or a 5xx status, with exponential backoff. A Retry-After
+// header in seconds takes precedence over the backoff.
+//
+// Only GET requests are retried by default. POST requests such as a code
+// exchange or a token refresh may have taken effect before a failure, so a
+// retry could fail with invalid_grant or use up a rotated refresh token.
+// Opt in per call with AllowRetry.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts. Values below 2 disable retries.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry. Default 200ms.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between attempts. Default 5s.
+	MaxDelay time.Duration
+}
+
+// WithRetryPolicy function
+func WithRetryPolicy(p RetryPolicy) ClientOption {
+	return func(client *Client) error {
+		client.retry = p
+		return nil
+	}
+}
+
+type allowRetryKey struct{}
+
+// AllowRetry returns a context under which non-GET requests are retried by
+// the client's RetryPolicy too, e.g.
+// client.RevokeToken(token).WithContext(social.AllowRetry(ctx)).Do().
+func AllowRetry(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, allowRetryKey{}, true)
+}
+
+// withoutRetry undoes AllowRetry for callers that retry on their own.
+func withoutRetry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allowRetryKey{}, nil)
+}
+
+// retryAllowed reports whether req may be sent more than once.
+func retryAllowed(ctx context.Context, req *http.Request) bool {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return true
+	}
+	return ctx != nil && ctx.Value(allowRetryKey{}) != nil
+}
+
+func (p RetryPolicy) attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// delay returns the wait before attempt+1.
+func (p RetryPolicy) delay(attempt int, res *http.Response) time.Duration {
+	base, max := p.BaseDelay, p.MaxDelay
+	if base <= 0 {
+		base = 200 * time.Millisecond
+	}
+	if max <= 0 {
+		max = 5 * time.Second
+	}
+	if res != nil {
+		if sec, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && sec >= 0 {
+			if d := time.Duration(sec) * time.Second; d < max {
+				return d
+			}
+			return max
+		}
+	}
+	d := base << (attempt - 1)
+	if d <= 0 || d > max {
+		d = max
+	}
+	return d
+}
+
+func retryable(res *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if ctx == nil {
+		time.Sleep(d)
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// discard drains and closes a response body so the connection can be reused.
+func discard(res *http.Response) {
+	if res != nil && res.Body != nil {
+		io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+		res.Body.Close()
+	}
+}
@@ -0,0 +1,202 @@
+package social
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Scopes https://developers.line.biz/en/docs/line-login/integrate-line-login/#scopes
+const (
+	ScopeProfile = "profile"
+	ScopeOpenID  = "openid"
+	ScopeEmail   = "email"
+
+	// LINE Profile+ scopes. They require an approved application.
+	// https://developers.line.biz/en/docs/partner-docs/line-profile-plus/
+	ScopeRealName  = "real_name"
+	ScopeGender    = "gender"
+	ScopeBirthdate = "birthdate"
+	ScopePhone     = "phone"
+	ScopeAddress   = "address"
+)
+
+// JoinScopes returns the scope parameter for GetWebLoinURL.
+func JoinScopes(scopes ...string) string {
+	return strings.Join(scopes, " ")
+}
+
+// ScopeSet is a set of granted or requested scopes.
+type ScopeSet map[string]struct{}
+
+// NewScopeSet returns a set of scopes.
+func NewScopeSet(scopes ...string) ScopeSet {
+	s := make(ScopeSet, len(scopes))
+	for _, scope := range scopes {
+		if scope != "" {
+			s[scope] = struct{}{}
+		}
+	}
+	return s
+}
+
+// ParseScopes parses a space-separated scope string such as
+// TokenResponse.Scope.
+func ParseScopes(scope string) ScopeSet {
+	return NewScopeSet(strings.Fields(scope)...)
+}
+
+// Has reports whether scope is in the set.
+func (s ScopeSet) Has(scope string) bool {
+	_, ok := s[scope]
+	return ok
+}
+
+// HasAll reports whether all scopes are in the set.
+func (s ScopeSet) HasAll(scopes ...string) bool {
+	return len(s.Missing(scopes...)) == 0
+}
+
+// Missing returns the required scopes not in the set, in the order given.
+func (s ScopeSet) Missing(required ...string) []string {
+	var missing []string
+	for _, r := range required {
+		if !s.Has(r) && !slices.Contains(missing, r) {
+			missing = append(missing, r)
+		}
+	}
+	return missing
+}
+
+// Union returns a set with the scopes of s and other.
+func (s ScopeSet) Union(other ScopeSet) ScopeSet {
+	u := make(ScopeSet, len(s)+len(other))
+	for scope := range s {
+		u[scope] = struct{}{}
+	}
+	for scope := range other {
+		u[scope] = struct{}{}
+	}
+	return u
+}
+
+// Equal reports whether both sets hold the same scopes.
+func (s ScopeSet) Equal(other ScopeSet) bool {
+	if len(s) != len(other) {
+		return false
+	}
+	for scope := range s {
+		if !other.Has(scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// Slice returns the scopes sorted.
+func (s ScopeSet) Slice() []string {
+	scopes := make([]string, 0, len(s))
+	for scope := range s {
+		scopes = append(scopes, scope)
+	}
+	slices.Sort(scopes)
+	return scopes
+}
+
+// String returns the sorted scopes separated by spaces.
+func (s ScopeSet) String() string {
+	return JoinScopes(s.Slice()...)
+}
+
+// Scopes returns the granted scopes.
+func (r *TokenResponse) Scopes() ScopeSet {
+	return ParseScopes(r.Scope)
+}
+
+// Scopes returns the granted scopes.
+func (r *TokenVerifyResponse) Scopes() ScopeSet {
+	return ParseScopes(r.Scope)
+}
+
+// IncrementalAuthURL returns an authorization URL with prompt=consent asking
+// for the required scopes that granted lacks together with the granted ones,
+// since the new access token only carries the scopes of its own request. It
+// returns an empty URL when nothing is missing. openid is added to requests
+// for email or Profile+ scopes, which LINE only returns in an ID token.
+func (client *Client) IncrementalAuthURL(redirectURL, state string, granted ScopeSet, required []string, options AuthRequestOptions) (string, error) {
+	missing := granted.Missing(required...)
+	if len(missing) == 0 {
+		return "", nil
+	}
+	request := granted.Union(NewScopeSet(missing...))
+	for _, scope := range missing {
+		if scope != ScopeProfile && scope != ScopeOpenID {
+			request[ScopeOpenID] = struct{}{}
+			break
+		}
+	}
+	options.Prompt = PromptConsent
+	return client.GetWebLoinURL(redirectURL, state, request.String(), options)
+}
+
+// InsufficientScopeError is returned when the LINE Platform rejects a call
+// because the access token lacks a scope. It matches ErrMissingScope with
+// errors.Is and unwraps to the *APIError.
+type InsufficientScopeError struct {
+	Required []string
+	Err      *APIError
+}
+
+// Error method
+func (e *InsufficientScopeError) Error() string {
+	return fmt.Sprintf("%s: %s: %v", ErrMissingScope, strings.Join(e.Required, " "), e.Err)
+}
+
+// Is method
+func (e *InsufficientScopeError) Is(target error) bool {
+	return target == ErrMissingScope
+}
+
+// Unwrap method
+func (e *InsufficientScopeError) Unwrap() error {
+	return e.Err
+}
+
+// scopeError turns a 403 from an endpoint requiring scopes into an
+// *InsufficientScopeError when the response blames a scope. Other 403s, such
+// as a channel that may not use the API, are returned as they are.
+func scopeError(err error, required ...string) error {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusForbidden && insufficientScope(apiErr.Response) {
+		return &InsufficientScopeError{Required: required, Err: apiErr}
+	}
+	return err
+}
+
+// insufficientScope reports whether an error response says the access token
+// lacks a scope.
+func insufficientScope(res *ErrorResponse) bool {
+	if res == nil {
+		return false
+	}
+	if res.Error == "insufficient_scope" {
+		return true
+	}
+	return strings.Contains(strings.ToLower(res.Message+" "+res.ErrorDescription), "scope")
+}
+
+// errorScopes returns the scopes a *MissingScopeError or
+// *InsufficientScopeError reports.
+func errorScopes(err error) []string {
+	var missingErr *MissingScopeError
+	if errors.As(err, &missingErr) {
+		return missingErr.Missing
+	}
+	var insufficientErr *InsufficientScopeError
+	if errors.As(err, &insufficientErr) {
+		return insufficientErr.Required
+	}
+	return nil
+}
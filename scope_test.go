@@ -0,0 +1,87 @@
+package social
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestScopeSet(t *testing.T) {
+	granted := ParseScopes("profile  openid profile")
+	if got := granted.String(); got != "openid profile" {
+		t.Errorf("String = %q", got)
+	}
+	if !granted.Has(ScopeOpenID) || granted.Has(ScopeEmail) || !granted.HasAll(ScopeProfile, ScopeOpenID) {
+		t.Errorf("Has/HasAll on %v", granted)
+	}
+	if got := granted.Missing(ScopeEmail, ScopeProfile, ScopeEmail, ScopePhone); !reflect.DeepEqual(got, []string{ScopeEmail, ScopePhone}) {
+		t.Errorf("Missing = %v", got)
+	}
+	union := granted.Union(NewScopeSet(ScopeEmail))
+	if !union.Equal(ParseScopes("email openid profile")) || union.Equal(granted) {
+		t.Errorf("Union = %v", union)
+	}
+	if got := (&TokenResponse{Scope: "profile openid"}).Scopes(); !got.Equal(granted) {
+		t.Errorf("TokenResponse.Scopes = %v", got)
+	}
+}
+
+func TestIncrementalAuthURL(t *testing.T) {
+	client, err := New("1234", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	granted := ParseScopes("profile")
+
+	got, err := client.IncrementalAuthURL("https://example.com/callback", "state", granted, []string{ScopeProfile}, AuthRequestOptions{})
+	if err != nil || got != "" {
+		t.Errorf("IncrementalAuthURL(nothing missing) = %q, %v", got, err)
+	}
+
+	got, err = client.IncrementalAuthURL("https://example.com/callback", "state", granted, []string{ScopeProfile, ScopeEmail}, AuthRequestOptions{Nonce: "n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(got)
+	q := u.Query()
+	if q.Get("scope") != "email openid profile" || q.Get("prompt") != PromptConsent || q.Get("nonce") != "n" {
+		t.Errorf("query = %v", q)
+	}
+}
+
+func TestInsufficientScopeError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		if r.URL.Path == APIEndpointGetFriendshipStratus {
+			fmt.Fprint(w, `{"message":"Not allowed to use this API"}`)
+			return
+		}
+		fmt.Fprint(w, `{"error":"insufficient_scope","error_description":"The access token does not have the required scope"}`)
+	}))
+	defer srv.Close()
+	client, err := New("1234", "secret", WithEndpointBase(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.GetUserInfo("token").Do()
+	var scopeErr *InsufficientScopeError
+	if !errors.As(err, &scopeErr) || !reflect.DeepEqual(scopeErr.Required, []string{ScopeOpenID}) {
+		t.Fatalf("GetUserInfo = %v", err)
+	}
+	var apiErr *APIError
+	if !errors.Is(err, ErrMissingScope) || !errors.As(err, &apiErr) || apiErr.Code != http.StatusForbidden {
+		t.Errorf("error chain of %v", err)
+	}
+	if _, err := client.GetUserProfile("token").Do(); !errors.Is(err, ErrMissingScope) {
+		t.Errorf("GetUserProfile = %v", err)
+	}
+	// A 403 that does not blame a scope is not reported as one.
+	if _, err := client.GetFriendshipStatus("token").Do(); errors.Is(err, ErrMissingScope) || !errors.As(err, &apiErr) {
+		t.Errorf("GetFriendshipStatus = %v", err)
+	}
+}
@@ -0,0 +1,191 @@
+package social
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultSecretGracePeriod is how long a rotated-out channel secret is still
+// accepted for verification.
+const DefaultSecretGracePeriod = 24 * time.Hour
+
+// SecretProvider supplies the channel secret. Outbound calls use only the
+// current secret; verification of HS256 ID tokens and signed values also
+// accepts the previous secrets.
+type SecretProvider interface {
+	// Secrets returns the current secret and the previous secrets that are
+	// still within their grace period.
+	Secrets() (current string, previous []string, err error)
+}
+
+// WithSecretProvider sets the source of the channel secret. The secret
+// passed to New may then be empty.
+func WithSecretProvider(p SecretProvider) ClientOption {
+	return func(client *Client) error {
+		client.secrets = p
+		return nil
+	}
+}
+
+func (client *Client) currentSecret() (string, error) {
+	current, _, err := client.secrets.Secrets()
+	if err != nil {
+		return "", err
+	}
+	if current == "" {
+		return "", errors.New("missing channel secret")
+	}
+	return current, nil
+}
+
+// verificationSecrets returns the current secret followed by the previous ones.
+func (client *Client) verificationSecrets() ([]string, error) {
+	current, previous, err := client.secrets.Secrets()
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{current}, previous...), nil
+}
+
+// StaticSecret returns a SecretProvider with a fixed current secret and
+// optional previous secrets, e.g. during a planned rotation.
+func StaticSecret(current string, previous ...string) SecretProvider {
+	return staticSecret{current: current, previous: previous}
+}
+
+type staticSecret struct {
+	current  string
+	previous []string
+}
+
+func (s staticSecret) Secrets() (string, []string, error) {
+	return s.current, s.previous, nil
+}
+
+// secretRotation remembers rotated-out secrets until their grace period ends.
+type secretRotation struct {
+	mu       sync.Mutex
+	grace    time.Duration
+	current  string
+	previous []rotatedSecret
+	now      func() time.Time
+}
+
+type rotatedSecret struct {
+	value string
+	until time.Time
+}
+
+func newSecretRotation(grace time.Duration) *secretRotation {
+	if grace <= 0 {
+		grace = DefaultSecretGracePeriod
+	}
+	return &secretRotation{grace: grace, now: time.Now}
+}
+
+// observe records value as the current secret and returns the secrets.
+func (r *secretRotation) observe(value string) (string, []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	if value != r.current {
+		if r.current != "" {
+			r.previous = append([]rotatedSecret{{value: r.current, until: now.Add(r.grace)}}, r.previous...)
+		}
+		r.current = value
+	}
+	var previous []string
+	kept := r.previous[:0]
+	for _, p := range r.previous {
+		if now.Before(p.until) && p.value != value {
+			kept = append(kept, p)
+			previous = append(previous, p.value)
+		}
+	}
+	r.previous = kept
+	return r.current, previous
+}
+
+// EnvSecretProvider reads the channel secret from an environment variable
+// on every use, so a changed value takes effect without rebuilding the Client.
+type EnvSecretProvider struct {
+	name     string
+	rotation *secretRotation
+}
+
+// NewEnvSecretProvider returns a provider reading the variable name. Secrets
+// replaced by a new value are still accepted for verification during grace.
+func NewEnvSecretProvider(name string, grace time.Duration) *EnvSecretProvider {
+	return &EnvSecretProvider{name: name, rotation: newSecretRotation(grace)}
+}
+
+// Secrets method
+func (p *EnvSecretProvider) Secrets() (string, []string, error) {
+	value := os.Getenv(p.name)
+	if value == "" {
+		return "", nil, fmt.Errorf("environment variable %s is not set", p.name)
+	}
+	current, previous := p.rotation.observe(value)
+	return current, previous, nil
+}
+
+// FileSecretProvider reads the channel secret from a file, such as a mounted
+// Kubernetes secret, and re-reads it when the file changes.
+type FileSecretProvider struct {
+	path     string
+	rotation *secretRotation
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	value   string
+}
+
+// NewFileSecretProvider returns a provider reading path. Surrounding
+// whitespace in the file is ignored. Secrets replaced by a new file content
+// are still accepted for verification during grace.
+func NewFileSecretProvider(path string, grace time.Duration) *FileSecretProvider {
+	return &FileSecretProvider{path: path, rotation: newSecretRotation(grace)}
+}
+
+// Secrets method
+func (p *FileSecretProvider) Secrets() (string, []string, error) {
+	value, err := p.read()
+	if err != nil {
+		return "", nil, err
+	}
+	current, previous := p.rotation.observe(value)
+	return current, previous, nil
+}
+
+// read returns the file content, re-reading it only when its size or
+// modification time changed.
+func (p *FileSecretProvider) read() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fi, err := os.Stat(p.path)
+	if err != nil {
+		if p.value != "" {
+			// Keep serving the last good secret while the file is replaced.
+			return p.value, nil
+		}
+		return "", err
+	}
+	if p.value != "" && fi.ModTime().Equal(p.modTime) && fi.Size() == p.size {
+		return p.value, nil
+	}
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return "", err
+	}
+	value := string(bytes.TrimSpace(data))
+	if value == "" {
+		return "", fmt.Errorf("secret file %s is empty", p.path)
+	}
+	p.value, p.modTime, p.size = value, fi.ModTime(), fi.Size()
+	return value, nil
+}
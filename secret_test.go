@@ -0,0 +1,83 @@
+package social
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEnvSecretProviderRotation(t *testing.T) {
+	t.Setenv("TEST_LINE_SECRET", "old")
+	p := NewEnvSecretProvider("TEST_LINE_SECRET", time.Hour)
+	now := time.Now()
+	p.rotation.now = func() time.Time { return now }
+
+	var sent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		sent = r.Form.Get("client_secret")
+		fmt.Fprint(w, `{}`)
+	}))
+	defer srv.Close()
+
+	client, err := New("1234", "", WithSecretProvider(p), WithEndpointBase(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldToken := testIDToken(t, "old", testClaims(now))
+	if _, err := client.ValidateIDToken(oldToken, "nonce-1").Do(); err != nil {
+		t.Fatalf("ValidateIDToken before rotation = %v", err)
+	}
+
+	t.Setenv("TEST_LINE_SECRET", "new")
+	if _, err := client.RefreshToken("refresh").Do(); err != nil {
+		t.Fatal(err)
+	}
+	if sent != "new" {
+		t.Errorf("client_secret sent = %q, want %q", sent, "new")
+	}
+	// The previous secret still verifies during the grace period...
+	if _, err := client.ValidateIDToken(oldToken, "nonce-1").Do(); err != nil {
+		t.Errorf("ValidateIDToken during grace = %v", err)
+	}
+	// ...and not after it.
+	now = now.Add(2 * time.Hour)
+	if _, err := client.ValidateIDToken(testIDToken(t, "old", testClaims(now)), "nonce-1").Do(); err == nil {
+		t.Errorf("ValidateIDToken after grace succeeded")
+	}
+}
+
+func TestFileSecretProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte("first\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p := NewFileSecretProvider(path, time.Hour)
+	current, previous, err := p.Secrets()
+	if err != nil || current != "first" || len(previous) != 0 {
+		t.Fatalf("Secrets() = %q, %v, %v", current, previous, err)
+	}
+
+	if err := os.WriteFile(path, []byte("second\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// Make sure the change is visible even on coarse mtime filesystems.
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	current, previous, err = p.Secrets()
+	if err != nil || current != "second" || len(previous) != 1 || previous[0] != "first" {
+		t.Errorf("Secrets() after rotation = %q, %v, %v", current, previous, err)
+	}
+}
+
+func TestNewRequiresSecret(t *testing.T) {
+	if _, err := New("1234", ""); err == nil {
+		t.Errorf("New without a secret succeeded")
+	}
+}
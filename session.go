@@ -0,0 +1,157 @@
+package social
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// TokenStore persists the token of one session, e.g. a row keyed by your
+// session ID.
+type TokenStore interface {
+	SaveToken(ctx context.Context, token *Token) error
+	DeleteToken(ctx context.Context) error
+}
+
+// Session calls the LINE APIs on behalf of one user. When the access token
+// is rejected with 401 it refreshes the token once, saves it to the store
+// and retries. It is safe for concurrent use.
+type Session struct {
+	client *Client
+	store  TokenStore
+
+	mu    sync.Mutex
+	token *Token
+}
+
+// Session returns a session for token. store may be nil when refreshed
+// tokens need not be saved. With a nil token every call returns
+// ErrReauthenticationRequired.
+func (client *Client) Session(token *Token, store TokenStore) *Session {
+	return &Session{client: client, store: store, token: token}
+}
+
+// Token returns a copy of the current token, or a zero Token when there is
+// none.
+func (s *Session) Token() Token {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token == nil {
+		return Token{}
+	}
+	return *s.token
+}
+
+// Profile method
+func (s *Session) Profile(ctx context.Context) (*GetUserProfileResponse, error) {
+	return withSession(ctx, s, func(accessToken string) (*GetUserProfileResponse, error) {
+		return s.client.GetUserProfile(accessToken).WithContext(ctx).Do()
+	})
+}
+
+// UserInfo method
+func (s *Session) UserInfo(ctx context.Context) (*GetUserInfoResponse, error) {
+	return withSession(ctx, s, func(accessToken string) (*GetUserInfoResponse, error) {
+		return s.client.GetUserInfo(accessToken).WithContext(ctx).Do()
+	})
+}
+
+// Friendship method
+func (s *Session) Friendship(ctx context.Context) (*GetFriendshipStatusResponse, error) {
+	return withSession(ctx, s, func(accessToken string) (*GetFriendshipStatusResponse, error) {
+		return s.client.GetFriendshipStatus(accessToken).WithContext(ctx).Do()
+	})
+}
+
+// Verify method
+func (s *Session) Verify(ctx context.Context) (*TokenVerifyResponse, error) {
+	return withSession(ctx, s, func(accessToken string) (*TokenVerifyResponse, error) {
+		return s.client.TokenVerify(accessToken).WithContext(ctx).Do()
+	})
+}
+
+// Revoke revokes the access token. The token is not refreshed first.
+func (s *Session) Revoke(ctx context.Context) error {
+	accessToken := s.Token().AccessToken
+	if accessToken == "" {
+		return ErrReauthenticationRequired
+	}
+	_, err := s.client.RevokeToken(accessToken).WithContext(ctx).Do()
+	return err
+}
+
+// Logout revokes the access token and deletes it from the store. See
+// Client.Logout.
+func (s *Session) Logout(ctx context.Context) error {
+	return s.client.Logout(ctx, LogoutRequest{AccessToken: s.Token().AccessToken, Store: s.store}).Err()
+}
+
+// withSession calls f with the access token and, when it is rejected with
+// 401, refreshes the token and calls f again. An expired access token is
+// refreshed before the first call.
+func withSession[T any](ctx context.Context, s *Session, f func(accessToken string) (T, error)) (T, error) {
+	s.mu.Lock()
+	token := s.token
+	s.mu.Unlock()
+	if token == nil {
+		var zero T
+		return zero, ErrReauthenticationRequired
+	}
+
+	accessToken := token.AccessToken
+	if !token.Valid() && token.CanRefresh() {
+		var err error
+		if accessToken, err = s.refresh(ctx, accessToken); err != nil {
+			var zero T
+			return zero, err
+		}
+	}
+	res, err := f(accessToken)
+	if !unauthorized(err) {
+		return res, err
+	}
+	if accessToken, err = s.refresh(ctx, accessToken); err != nil {
+		var zero T
+		return zero, err
+	}
+	return f(accessToken)
+}
+
+// refresh replaces the token unless another call has already replaced
+// stale, and returns the new access token.
+func (s *Session) refresh(ctx context.Context, stale string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token.AccessToken != stale {
+		return s.token.AccessToken, nil
+	}
+	if !s.token.CanRefresh() {
+		return "", ErrReauthenticationRequired
+	}
+	res, err := s.client.RefreshToken(s.token.RefreshToken).WithContext(ctx).Do()
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.Response != nil && apiErr.Response.Error == "invalid_grant" {
+		return "", fmt.Errorf("%w: %w", ErrReauthenticationRequired, err)
+	}
+	if err != nil {
+		return "", err
+	}
+	// LINE has issued a new refresh token, so the session keeps the token
+	// even when saving it fails.
+	token := *s.token
+	token.Merge(res)
+	s.token = &token
+	if s.store != nil {
+		if err := s.store.SaveToken(ctx, &token); err != nil {
+			return "", fmt.Errorf("save refreshed token: %w", err)
+		}
+	}
+	return token.AccessToken, nil
+}
+
+func unauthorized(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusUnauthorized
+}
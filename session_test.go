@@ -0,0 +1,133 @@
+package social
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type testTokenStore struct {
+	saved   *Token
+	deleted bool
+	saveErr error
+}
+
+func (s *testTokenStore) SaveToken(ctx context.Context, token *Token) error {
+	if s.saveErr != nil {
+		return s.saveErr
+	}
+	s.saved = token
+	return nil
+}
+
+func (s *testTokenStore) DeleteToken(ctx context.Context) error {
+	s.deleted = true
+	return nil
+}
+
+func TestSession(t *testing.T) {
+	var refreshes, revokes int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case APIEndpointGetUserProfile:
+			if r.Header.Get("Authorization") != "Bearer new-access" {
+				w.WriteHeader(http.StatusUnauthorized)
+				fmt.Fprint(w, `{"message":"The access token expired"}`)
+				return
+			}
+			fmt.Fprint(w, `{"userId":"U1234","displayName":"Brown"}`)
+		case APIEndpointToken:
+			refreshes++
+			r.ParseForm()
+			if r.PostForm.Get("refresh_token") == "other-channel" {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"error":"invalid_client","error_description":"invalid client"}`)
+				return
+			}
+			if r.PostForm.Get("refresh_token") != "good-refresh" {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"error":"invalid_grant","error_description":"invalid refresh token"}`)
+				return
+			}
+			fmt.Fprint(w, `{"access_token":"new-access","expires_in":2592000,"refresh_token":"next-refresh","scope":"profile openid","token_type":"Bearer"}`)
+		case APIEndpointRevokeToken:
+			revokes++
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+	client, err := New("1234", "secret", WithEndpointBase(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	newToken := func(refreshToken string) *Token {
+		return NewToken(&TokenResponse{AccessToken: "old-access", ExpiresIn: 3600, RefreshToken: refreshToken, IDToken: "id-token"}, nil)
+	}
+
+	store := &testTokenStore{}
+	session := client.Session(newToken("good-refresh"), store)
+	profile, err := session.Profile(ctx)
+	if err != nil || profile.UserID != "U1234" {
+		t.Fatalf("Profile = %+v, %v", profile, err)
+	}
+	if store.saved == nil || store.saved.AccessToken != "new-access" || store.saved.RefreshToken != "next-refresh" || store.saved.IDToken != "id-token" {
+		t.Errorf("saved token = %+v", store.saved)
+	}
+	if _, err := session.Profile(ctx); err != nil || refreshes != 1 {
+		t.Errorf("second Profile = %v, refreshes = %d", err, refreshes)
+	}
+
+	// A rejected refresh token needs a new login.
+	session = client.Session(newToken("bad-refresh"), nil)
+	if _, err := session.Profile(ctx); !errors.Is(err, ErrReauthenticationRequired) {
+		t.Errorf("Profile with bad refresh token = %v", err)
+	}
+
+	// A client error is not the user's fault.
+	session = client.Session(newToken("other-channel"), nil)
+	if _, err := session.Profile(ctx); err == nil || errors.Is(err, ErrReauthenticationRequired) {
+		t.Errorf("Profile with invalid_client = %v", err)
+	}
+
+	// A failed save keeps the refreshed token in the session.
+	saveErr := errors.New("db down")
+	session = client.Session(newToken("good-refresh"), &testTokenStore{saveErr: saveErr})
+	if _, err := session.Profile(ctx); !errors.Is(err, saveErr) {
+		t.Errorf("Profile with failing store = %v", err)
+	}
+	if token := session.Token(); token.AccessToken != "new-access" || token.RefreshToken != "next-refresh" {
+		t.Errorf("token after failed save = %+v", token)
+	}
+
+	// A refresh token past its window is not sent.
+	expired := newToken("good-refresh")
+	expired.Clock = func() time.Time { return time.Now().Add(RefreshTokenValidity + 2*time.Hour) }
+	refreshes = 0
+	if _, err := client.Session(expired, nil).Profile(ctx); !errors.Is(err, ErrReauthenticationRequired) || refreshes != 0 {
+		t.Errorf("Profile with expired refresh token = %v, refreshes = %d", err, refreshes)
+	}
+
+	session = client.Session(newToken("good-refresh"), store)
+	if err := session.Logout(ctx); err != nil || revokes != 1 || !store.deleted {
+		t.Errorf("Logout = %v, revokes = %d, deleted = %v", err, revokes, store.deleted)
+	}
+
+	// Without a token the user must log in; nothing is sent.
+	refreshes, revokes = 0, 0
+	session = client.Session(nil, nil)
+	if _, err := session.Profile(ctx); !errors.Is(err, ErrReauthenticationRequired) {
+		t.Errorf("Profile without token = %v", err)
+	}
+	if err := session.Revoke(ctx); !errors.Is(err, ErrReauthenticationRequired) {
+		t.Errorf("Revoke without token = %v", err)
+	}
+	if token := session.Token(); token.AccessToken != "" || !token.Expiry.IsZero() || refreshes != 0 || revokes != 0 {
+		t.Errorf("Token() = %+v, refreshes = %d, revokes = %d", token, refreshes, revokes)
+	}
+}
@@ -5,15 +5,29 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
 	"strings"
 )
 
+// Prompt values for AuthRequestOptions.Prompt
+const (
+	// PromptConsent forces the consent screen, e.g. to ask for more scopes.
+	PromptConsent = "consent"
+	// PromptLogin forces the user to log in again.
+	PromptLogin = "login"
+)
+
 type AuthRequestOptions struct {
-	Nonce     string
-	Prompt    string
+	Nonce  string
+	Prompt string
+	// MaxAge is the allowable elapsed time in seconds since the user was
+	// last authenticated; 0 omits max_age.
 	MaxAge    int
 	UILocales string
 	BotPrompt string
+	// DisableAutoLogin turns off auto login and SSO, so the user must log
+	// in with an email and password or a QR code.
+	DisableAutoLogin bool
 }
 
 // GetAcceessToken: Issues access token.
@@ -42,6 +56,13 @@ func (call *GetAccessTokenCall) WithContext(ctx context.Context) *GetAccessToken
 
 // Do method
 func (call *GetAccessTokenCall) Do() (*TokenResponse, error) {
+	if err := call.c.checkRedirectURL(call.redirectURL); err != nil {
+		return nil, err
+	}
+	secret, err := call.c.currentSecret()
+	if err != nil {
+		return nil, err
+	}
 	data := url.Values{}
 	// authorization_code. Specifies the grant type.
 	data.Set("grant_type", "authorization_code")
@@ -49,8 +70,12 @@ func (call *GetAccessTokenCall) Do() (*TokenResponse, error) {
 	data.Set("code", call.code)
 	data.Set("redirect_uri", call.redirectURL)
 	data.Set("client_id", call.c.channelID)
-	data.Set("client_secret", call.c.channelSecret)
+	data.Set("client_secret", secret)
 
+	// The code is only marked as used once nothing local can fail anymore.
+	if err := call.c.useOnce(call.ctx, ReplayKindCode, call.code, AuthorizationCodeTTL); err != nil {
+		return nil, err
+	}
 	res, err := call.c.post(call.ctx, APIEndpointToken, strings.NewReader(data.Encode()))
 	if res != nil && res.Body != nil {
 		defer res.Body.Close()
@@ -63,6 +88,9 @@ func (call *GetAccessTokenCall) Do() (*TokenResponse, error) {
 
 // GetWebLoinURL - LINE LOGIN 2.1 get LINE Login  authorization request URL
 func (client *Client) GetWebLoinURL(redirectURL string, state string, scope string, options AuthRequestOptions) (string, error) {
+	if err := client.checkRedirectURL(redirectURL); err != nil {
+		return "", err
+	}
 	u, err := url.Parse(APIEndpointAuthBase)
 	if err != nil {
 		return "", err
@@ -87,6 +115,10 @@ func (client *Client) GetWebLoinURL(redirectURL string, state string, scope stri
 		q.Add("prompt", options.Prompt)
 	}
 
+	if options.MaxAge > 0 {
+		q.Add("max_age", strconv.Itoa(options.MaxAge))
+	}
+
 	if len(options.UILocales) > 0 {
 		q.Add("ui_locales", options.UILocales)
 	}
@@ -95,12 +127,19 @@ func (client *Client) GetWebLoinURL(redirectURL string, state string, scope stri
 		q.Add("bot_prompt", options.BotPrompt)
 	}
 
+	if options.DisableAutoLogin {
+		q.Add("disable_auto_login", "true")
+	}
+
 	req.URL.RawQuery = q.Encode()
 	return req.URL.String(), nil
 }
 
 // GetPKCEWebLoinURL - LINE LOGIN 2.1 get LINE Login authorization request URL by PKCE
 func (client *Client) GetPKCEWebLoinURL(redirectURL string, state string, scope string, codeChallenge string, options AuthRequestOptions) (string, error) {
+	if err := client.checkRedirectURL(redirectURL); err != nil {
+		return "", err
+	}
 	u, err := url.Parse(APIEndpointAuthBase)
 	if err != nil {
 		return "", err
@@ -127,6 +166,10 @@ func (client *Client) GetPKCEWebLoinURL(redirectURL string, state string, scope
 		q.Add("prompt", options.Prompt)
 	}
 
+	if options.MaxAge > 0 {
+		q.Add("max_age", strconv.Itoa(options.MaxAge))
+	}
+
 	if len(options.UILocales) > 0 {
 		q.Add("ui_locales", options.UILocales)
 	}
@@ -135,6 +178,10 @@ func (client *Client) GetPKCEWebLoinURL(redirectURL string, state string, scope
 		q.Add("bot_prompt", options.BotPrompt)
 	}
 
+	if options.DisableAutoLogin {
+		q.Add("disable_auto_login", "true")
+	}
+
 	req.URL.RawQuery = q.Encode()
 	return req.URL.String(), nil
 }
@@ -164,12 +211,7 @@ func (call *TokenVerifyCall) WithContext(ctx context.Context) *TokenVerifyCall {
 
 // Do method
 func (call *TokenVerifyCall) Do() (*TokenVerifyResponse, error) {
-	u, err := url.Parse(APIEndpointBase)
-	if err != nil {
-		return nil, err
-	}
-	u.Path = path.Join(u.Path, APIEndpointTokenVerify)
-	req, err := http.NewRequest("GET", u.String(), nil)
+	req, err := http.NewRequest("GET", call.c.url(APIEndpointTokenVerify), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -214,11 +256,15 @@ func (call *RefreshTokenCall) WithContext(ctx context.Context) *RefreshTokenCall
 
 // Do method
 func (call *RefreshTokenCall) Do() (*TokenRefreshResponse, error) {
+	secret, err := call.c.currentSecret()
+	if err != nil {
+		return nil, err
+	}
 	data := url.Values{}
 	data.Set("grant_type", "refresh_token")
 	data.Set("refresh_token", call.refreshToken)
 	data.Set("client_id", call.c.channelID)
-	data.Set("client_secret", call.c.channelSecret)
+	data.Set("client_secret", secret)
 
 	res, err := call.c.post(call.ctx, APIEndpointToken, strings.NewReader(data.Encode()))
 	if res != nil && res.Body != nil {
@@ -256,10 +302,14 @@ func (call *RevokeTokenCall) WithContext(ctx context.Context) *RevokeTokenCall {
 
 // Do method
 func (call *RevokeTokenCall) Do() (*BasicResponse, error) {
+	secret, err := call.c.currentSecret()
+	if err != nil {
+		return nil, err
+	}
 	data := url.Values{}
 	data.Set("access_token", call.accessToken)
 	data.Set("client_id", call.c.channelID)
-	data.Set("client_secret", call.c.channelSecret)
+	data.Set("client_secret", secret)
 
 	res, err := call.c.post(call.ctx, APIEndpointRevokeToken, strings.NewReader(data.Encode()))
 	if res != nil && res.Body != nil {
@@ -284,9 +334,14 @@ func (client *Client) VerifyIDToken(iDToken string, options VerifyIDTokenRequest
 	}
 }
 
+// VerifyIDTokenRequestOptions type
 type VerifyIDTokenRequestOptions struct {
-	nonce  string
-	userID string
+	// Nonce: Expected nonce value. Use the nonce value provided in the authorization request.
+	Nonce string
+	// UserID: Expected user ID.
+	UserID string
+	// ClientID: Expected channel ID. Defaults to the client's channel.
+	ClientID string
 }
 
 // VerifyIDTokenCall type
@@ -309,13 +364,24 @@ func (call *VerifyIDTokenCall) Do() (*VerifyIDTokenResponse, error) {
 	data := url.Values{}
 	data.Set("id_token", call.iDToken)
 	data.Set("client_id", call.c.channelID)
+	if call.options.ClientID != "" {
+		data.Set("client_id", call.options.ClientID)
+	}
+
+	if call.options.Nonce != "" {
+		data.Set("nonce", call.options.Nonce)
+	}
 
-	if call.options.nonce != "" {
-		data.Set("nonce", call.options.nonce)
+	if call.options.UserID != "" {
+		data.Set("user_id", call.options.UserID)
 	}
 
-	if call.options.userID != "" {
-		data.Set("user_id", call.options.userID)
+	// The expected nonce is known up front, so a replay is rejected before
+	// the request is sent.
+	if call.options.Nonce != "" {
+		if err := call.c.useOnce(call.ctx, ReplayKindNonce, call.options.Nonce, IDTokenTTL+idTokenLeeway); err != nil {
+			return nil, err
+		}
 	}
 
 	res, err := call.c.post(call.ctx, APIEndpointTokenVerify, strings.NewReader(data.Encode()))
@@ -364,7 +430,8 @@ func (call *GetUserProfileCall) Do() (*GetUserProfileResponse, error) {
 	if err != nil {
 		return nil, err
 	}
-	return decodeToGetUserProfileResponse(res)
+	result, err := decodeToGetUserProfileResponse(res)
+	return result, scopeError(err, ScopeProfile)
 }
 
 // GetFriendshipStatus: Gets the friendship status of the user and the bot linked to your LINE Login channel.
@@ -402,7 +469,8 @@ func (call *GetFriendshipStatusCall) Do() (*GetFriendshipStatusResponse, error)
 	if err != nil {
 		return nil, err
 	}
-	return decodeToGetFriendshipStatusResponse(res)
+	result, err := decodeToGetFriendshipStatusResponse(res)
+	return result, scopeError(err, ScopeProfile)
 }
 
 // GetUserInfo: Gets a user's ID, display name, and profile image.
@@ -441,7 +509,8 @@ func (call *GetUserInfoCall) Do() (*GetUserInfoResponse, error) {
 	if err != nil {
 		return nil, err
 	}
-	return decodeToGetUserInfoResponse(res)
+	result, err := decodeToGetUserInfoResponse(res)
+	return result, scopeError(err, ScopeOpenID)
 }
 
 // Deauthorize: Revokes all permissions granted by a user and deauthorizes the application.
@@ -518,6 +587,13 @@ func (call *GetAccessTokenPKCECall) WithContext(ctx context.Context) *GetAccessT
 
 // Do method
 func (call *GetAccessTokenPKCECall) Do() (*TokenResponse, error) {
+	if err := call.c.checkRedirectURL(call.redirectURL); err != nil {
+		return nil, err
+	}
+	secret, err := call.c.currentSecret()
+	if err != nil {
+		return nil, err
+	}
 	data := url.Values{}
 	// authorization_code. Specifies the grant type.
 	data.Set("grant_type", "authorization_code")
@@ -525,9 +601,13 @@ func (call *GetAccessTokenPKCECall) Do() (*TokenResponse, error) {
 	data.Set("code", call.code)
 	data.Set("redirect_uri", call.redirectURL)
 	data.Set("client_id", call.c.channelID)
-	data.Set("client_secret", call.c.channelSecret)
+	data.Set("client_secret", secret)
 	data.Set("code_verifier", call.codeVerifier)
 
+	// The code is only marked as used once nothing local can fail anymore.
+	if err := call.c.useOnce(call.ctx, ReplayKindCode, call.code, AuthorizationCodeTTL); err != nil {
+		return nil, err
+	}
 	res, err := call.c.post(call.ctx, APIEndpointToken, strings.NewReader(data.Encode()))
 	if res != nil && res.Body != nil {
 		defer res.Body.Close()
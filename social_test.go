@@ -1,11 +1,17 @@
-package social
+package social_test
 
 import (
-	"log"
 	"os"
+	"path/filepath"
 	"testing"
+
+	social "github.com/kkdai/line-login-sdk-go"
+	"github.com/kkdai/line-login-sdk-go/socialtest"
 )
 
+// The tests replay the fixtures under testdata/recorder, so they run offline
+// without any credentials. To record a fixture again, delete it and set the
+// LINE_* variables below; secrets and tokens are scrubbed before saving.
 var (
 	accessToken  string
 	refreshToken string
@@ -15,185 +21,181 @@ var (
 	qURL         string
 	code         string
 	userID       string
+	idNonce      string
 )
 
-// Provide those data for testing
+// Provide those data for recording
 func init() {
-	accessToken = os.Getenv("LINE_ACCESS_TOKEN")
-	refreshToken = os.Getenv("LINE_REFRESH_TOKEN")
-	iDToken = os.Getenv("LINE_ID_TOKEN")
-	cID = os.Getenv("LINE_CLIENT_ID")
-	cSecret = os.Getenv("LINE_CLIENT_SECRET")
-	qURL = os.Getenv("LINE_SERVER_URL")
-	code = os.Getenv("LINE_LOGIN_CODE")
-	userID = os.Getenv("LINE_USER_ID")
+	accessToken = getenv("LINE_ACCESS_TOKEN", "access-token")
+	refreshToken = getenv("LINE_REFRESH_TOKEN", "refresh-token")
+	iDToken = getenv("LINE_ID_TOKEN", "id-token")
+	cID = getenv("LINE_CLIENT_ID", "1234567890")
+	cSecret = getenv("LINE_CLIENT_SECRET", "channel-secret")
+	qURL = getenv("LINE_SERVER_URL", "https://example.com/callback")
+	code = getenv("LINE_LOGIN_CODE", "login-code")
+	userID = getenv("LINE_USER_ID", "U1234567890abcdef1234567890abcdef")
+	idNonce = getenv("LINE_ID_TOKEN_NONCE", "nonce")
 }
 
-func checkEnvVariables(t *testing.T) {
-	if len(cID) == 0 || len(cSecret) == 0 {
-		log.Println("Please set environment variables for your LINE setting")
-		t.Skip("Please set environment variables for your LINE setting")
+func getenv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
 	}
+	return fallback
 }
 
-func TestGetAccessToken(t *testing.T) {
-	checkEnvVariables(t)
-
-	if len(code) == 0 {
-		t.Skip("Skip it since don't exist LINE login code.")
-	}
+// newClient returns a client replaying the test's fixture. Without a fixture
+// the interactions are recorded, which needs the channel credentials and the
+// given variables.
+func newClient(t *testing.T, required ...string) *social.Client {
+	t.Helper()
+	rec, err := socialtest.NewRecorder(filepath.Join("testdata", "recorder", t.Name()+".json"), socialtest.ModeAuto,
+		// The channel, callback and user differ between recording and replaying.
+		socialtest.WithIgnoredFields("client_id", "redirect_uri", "user_id"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Mode() == socialtest.ModeRecord {
+		for _, key := range append([]string{"LINE_CLIENT_ID", "LINE_CLIENT_SECRET"}, required...) {
+			if os.Getenv(key) == "" {
+				t.Skipf("no fixture to replay; set %s to record one", key)
+			}
+		}
+	}
+	t.Cleanup(func() {
+		if err := rec.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+	options := []social.ClientOption{social.WithHTTPClient(rec.Client())}
+	if base := os.Getenv("LINE_ENDPOINT_BASE"); base != "" {
+		options = append(options, social.WithEndpointBase(base))
+	}
+	client, err := social.New(cID, cSecret, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
 
-	client, _ := New(cID, cSecret)
+func TestGetAccessToken(t *testing.T) {
+	client := newClient(t, "LINE_SERVER_URL", "LINE_LOGIN_CODE")
 	ret, err := client.GetAccessToken(qURL, code).Do()
 	if err != nil {
-		t.Errorf("err: %v", err)
+		t.Fatalf("err: %v", err)
+	}
+	if ret.AccessToken == "" || ret.TokenType != "Bearer" || ret.ExpiresIn == 0 {
+		t.Errorf("data = %+v", ret)
 	}
-
-	t.Logf(" data:= %+v", ret)
 }
 
 func TestGetUserProfile(t *testing.T) {
-	checkEnvVariables(t)
-
-	client, _ := New(cID, cSecret)
+	client := newClient(t, "LINE_ACCESS_TOKEN")
 	ret, err := client.GetUserProfile(accessToken).Do()
 	if err != nil {
-		log.Println("err:", err)
+		t.Fatalf("err: %v", err)
+	}
+	if ret.UserID == "" || ret.DisplayName == "" {
+		t.Errorf("ret = %+v", ret)
 	}
-
-	log.Println("ret:", ret)
 }
 
 func TestGetURLCode(t *testing.T) {
-	checkEnvVariables(t)
-
 	scope := "profile openid" //profile | openid | email
-	state, err := GenerateNonce()
+	state, err := social.GenerateState()
 	if err != nil {
-		t.Errorf("GenerateNonce Error: %v", err)
-		return
+		t.Fatalf("GenerateState Error: %v", err)
 	}
-	nonce, err := GenerateNonce()
+	nonce, err := social.GenerateNonce()
 	if err != nil {
-		t.Errorf("GenerateNonce Error: %v", err)
-		return
+		t.Fatalf("GenerateNonce Error: %v", err)
 	}
 
-	client, _ := New(cID, cSecret)
-	url, err := client.GetWebLoinURL(qURL, state, scope, AuthRequestOptions{Nonce: nonce, BotPrompt: "normal", Prompt: "consent"})
+	client, _ := social.New(cID, cSecret)
+	url, err := client.GetWebLoinURL(qURL, state, scope, social.AuthRequestOptions{Nonce: nonce, BotPrompt: "normal", Prompt: "consent"})
 	if err != nil {
-		t.Errorf("err: %v", err)
-		return
+		t.Fatalf("err: %v", err)
 	}
-	log.Println("url: ", url)
+	t.Log("url: ", url)
 }
 
 func TestPKCEGetURLCode(t *testing.T) {
-	checkEnvVariables(t)
-
 	scope := "profile openid" //profile | openid | email
-	state, err := GenerateNonce()
+	state, err := social.GenerateState()
 	if err != nil {
-		t.Errorf("GenerateNonce Error: %v", err)
-		return
+		t.Fatalf("GenerateState Error: %v", err)
 	}
-	nonce, err := GenerateNonce()
+	nonce, err := social.GenerateNonce()
 	if err != nil {
-		t.Errorf("GenerateNonce Error: %v", err)
-		return
+		t.Fatalf("GenerateNonce Error: %v", err)
 	}
 
-	codeVer, err := GenerateCodeVerifier(43)
+	codeVer, err := social.GenerateCodeVerifier(43)
 	if err != nil {
-		t.Errorf("GenerateCodeVerifier Error: %v", err)
-		return
+		t.Fatalf("GenerateCodeVerifier Error: %v", err)
 	}
-	codeChallenge := PkceChallenge(codeVer)
+	codeChallenge := social.PkceChallenge(codeVer)
 
-	client, _ := New(cID, cSecret)
-	url, err := client.GetPKCEWebLoinURL(qURL, state, scope, codeChallenge, AuthRequestOptions{Nonce: nonce, BotPrompt: "normal", Prompt: "consent"})
+	client, _ := social.New(cID, cSecret)
+	url, err := client.GetPKCEWebLoinURL(qURL, state, scope, codeChallenge, social.AuthRequestOptions{Nonce: nonce, BotPrompt: "normal", Prompt: "consent"})
 	if err != nil {
-		t.Errorf("err: %v", err)
-		return
+		t.Fatalf("err: %v", err)
 	}
-	log.Println("url: ", url)
+	t.Log("url: ", url)
 }
 
 func TestVerifyToken(t *testing.T) {
-	checkEnvVariables(t)
-
-	client, _ := New(cID, cSecret)
+	client := newClient(t, "LINE_ACCESS_TOKEN")
 	ret, err := client.TokenVerify(accessToken).Do()
 	if err != nil {
-		log.Println("err:", err)
+		t.Fatalf("err: %v", err)
+	}
+	if ret.ClientID == "" || ret.ExpiresIn == 0 {
+		t.Errorf("ret = %+v", ret)
 	}
-
-	log.Println("ret:", ret)
 }
 
 func TestRefreshToken(t *testing.T) {
-	checkEnvVariables(t)
-
-	client, _ := New(cID, cSecret)
+	client := newClient(t, "LINE_REFRESH_TOKEN")
 	ret, err := client.RefreshToken(refreshToken).Do()
 	if err != nil {
-		log.Println("err:", err)
+		t.Fatalf("err: %v", err)
+	}
+	if ret.AccessToken == "" || ret.RefreshToken == "" {
+		t.Errorf("ret = %+v", ret)
 	}
-
-	log.Println("ret:", ret)
 }
 
 func TestRevokeToken(t *testing.T) {
-	checkEnvVariables(t)
-
-	client, _ := New(cID, cSecret)
-	ret, err := client.RevokeToken(accessToken).Do()
-	if err != nil {
-		log.Println("err:", err)
+	client := newClient(t, "LINE_ACCESS_TOKEN")
+	if _, err := client.RevokeToken(accessToken).Do(); err != nil {
+		t.Fatalf("err: %v", err)
 	}
-
-	log.Println("ret:", ret)
 }
 
 func TestVerifyIDToken(t *testing.T) {
-	checkEnvVariables(t)
-
-	nonce, err := GenerateNonce()
-	if err != nil {
-		t.Errorf("GenerateNonce Error: %v", err)
-		return
-	}
-
-	client, _ := New(cID, cSecret)
-	ret, err := client.VerifyIDToken(iDToken, VerifyIDTokenRequestOptions{
-		nonce:  nonce,
-		userID: userID,
+	client := newClient(t, "LINE_ID_TOKEN", "LINE_ID_TOKEN_NONCE", "LINE_USER_ID")
+	ret, err := client.VerifyIDToken(iDToken, social.VerifyIDTokenRequestOptions{
+		Nonce:  idNonce,
+		UserID: userID,
 	}).Do()
 	if err != nil {
-		log.Println("err:", err)
+		t.Fatalf("err: %v", err)
+	}
+	if ret.Sub == "" || ret.Iss != "https://access.line.me" {
+		t.Errorf("ret = %+v", ret)
 	}
-
-	log.Println("ret:", ret)
 }
 
 func TestGetAccessTokenPKCE(t *testing.T) {
-	checkEnvVariables(t)
-
-	codeVer, err := GenerateCodeVerifier(43)
+	// The verifier must be the one the login code was requested with.
+	codeVer := getenv("LINE_CODE_VERIFIER", "code-verifier")
+	client := newClient(t, "LINE_SERVER_URL", "LINE_LOGIN_CODE", "LINE_CODE_VERIFIER")
+	ret, err := client.GetAccessTokenPKCE(qURL, code, codeVer).Do()
 	if err != nil {
-		t.Errorf("GenerateCodeVerifier Error: %v", err)
-		return
-	}
-
-	if len(code) == 0 {
-		t.Skip("Skip it since don't exist LINE login code.")
+		t.Fatalf("err: %v", err)
 	}
-
-	client, _ := New(cID, cSecret)
-	ret, err := client.GetAccessTokenPKCE(codeVer, qURL, code).Do()
-	if err != nil {
-		t.Errorf("err: %v", err)
+	if ret.AccessToken == "" || ret.TokenType != "Bearer" {
+		t.Errorf("data = %+v", ret)
 	}
-
-	t.Logf(" data:= %+v", ret)
 }
@@ -0,0 +1,158 @@
+// Package socialtest provides test doubles for code built on the LINE Login SDK.
+package socialtest
+
+import (
+	"context"
+	"sync"
+
+	social "github.com/kkdai/line-login-sdk-go"
+)
+
+// Call records a single invocation of a Fake method.
+type Call struct {
+	// Method is the LoginAPI method name, e.g. "ExchangeCode".
+	Method string
+	// Args holds the string arguments in declaration order, without the
+	// context. Option structs are flattened in field order, e.g. VerifyIDToken
+	// records the ID token, Nonce, UserID and ClientID.
+	Args []string
+}
+
+// Fake is a scriptable, in-memory social.LoginAPI. Set the *Func fields to
+// script responses; a method whose func is nil returns an empty response and
+// no error. Every call is recorded and can be inspected with Calls.
+type Fake struct {
+	ExchangeCodeFunc  func(ctx context.Context, redirectURL, code, codeVerifier string) (*social.TokenResponse, error)
+	RefreshFunc       func(ctx context.Context, refreshToken string) (*social.TokenRefreshResponse, error)
+	VerifyFunc        func(ctx context.Context, accessToken string) (*social.TokenVerifyResponse, error)
+	RevokeFunc        func(ctx context.Context, accessToken string) (*social.BasicResponse, error)
+	ProfileFunc       func(ctx context.Context, accessToken string) (*social.GetUserProfileResponse, error)
+	UserInfoFunc      func(ctx context.Context, accessToken string) (*social.GetUserInfoResponse, error)
+	FriendshipFunc    func(ctx context.Context, accessToken string) (*social.GetFriendshipStatusResponse, error)
+	DeauthorizeFunc   func(ctx context.Context, channelAccessToken, userAccessToken string) (*social.BasicResponse, error)
+	VerifyIDTokenFunc func(ctx context.Context, idToken string, options social.VerifyIDTokenRequestOptions) (*social.VerifyIDTokenResponse, error)
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+var _ social.LoginAPI = (*Fake)(nil)
+
+// NewFake returns a Fake with no scripted responses.
+func NewFake() *Fake {
+	return &Fake{}
+}
+
+// Calls returns a copy of the calls received so far, in order.
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	calls := make([]Call, len(f.calls))
+	copy(calls, f.calls)
+	return calls
+}
+
+// CallsTo returns the recorded calls to method, in order.
+func (f *Fake) CallsTo(method string) []Call {
+	var calls []Call
+	for _, c := range f.Calls() {
+		if c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// Reset forgets all recorded calls. Scripted funcs are kept.
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = nil
+}
+
+func (f *Fake) record(method string, args ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, Call{Method: method, Args: args})
+}
+
+// ExchangeCode implements social.LoginAPI.
+func (f *Fake) ExchangeCode(ctx context.Context, redirectURL, code, codeVerifier string) (*social.TokenResponse, error) {
+	f.record("ExchangeCode", redirectURL, code, codeVerifier)
+	if f.ExchangeCodeFunc != nil {
+		return f.ExchangeCodeFunc(ctx, redirectURL, code, codeVerifier)
+	}
+	return &social.TokenResponse{}, nil
+}
+
+// Refresh implements social.LoginAPI.
+func (f *Fake) Refresh(ctx context.Context, refreshToken string) (*social.TokenRefreshResponse, error) {
+	f.record("Refresh", refreshToken)
+	if f.RefreshFunc != nil {
+		return f.RefreshFunc(ctx, refreshToken)
+	}
+	return &social.TokenRefreshResponse{}, nil
+}
+
+// Verify implements social.LoginAPI.
+func (f *Fake) Verify(ctx context.Context, accessToken string) (*social.TokenVerifyResponse, error) {
+	f.record("Verify", accessToken)
+	if f.VerifyFunc != nil {
+		return f.VerifyFunc(ctx, accessToken)
+	}
+	return &social.TokenVerifyResponse{}, nil
+}
+
+// Revoke implements social.LoginAPI.
+func (f *Fake) Revoke(ctx context.Context, accessToken string) (*social.BasicResponse, error) {
+	f.record("Revoke", accessToken)
+	if f.RevokeFunc != nil {
+		return f.RevokeFunc(ctx, accessToken)
+	}
+	return &social.BasicResponse{}, nil
+}
+
+// Profile implements social.LoginAPI.
+func (f *Fake) Profile(ctx context.Context, accessToken string) (*social.GetUserProfileResponse, error) {
+	f.record("Profile", accessToken)
+	if f.ProfileFunc != nil {
+		return f.ProfileFunc(ctx, accessToken)
+	}
+	return &social.GetUserProfileResponse{}, nil
+}
+
+// UserInfo implements social.LoginAPI.
+func (f *Fake) UserInfo(ctx context.Context, accessToken string) (*social.GetUserInfoResponse, error) {
+	f.record("UserInfo", accessToken)
+	if f.UserInfoFunc != nil {
+		return f.UserInfoFunc(ctx, accessToken)
+	}
+	return &social.GetUserInfoResponse{}, nil
+}
+
+// Friendship implements social.LoginAPI.
+func (f *Fake) Friendship(ctx context.Context, accessToken string) (*social.GetFriendshipStatusResponse, error) {
+	f.record("Friendship", accessToken)
+	if f.FriendshipFunc != nil {
+		return f.FriendshipFunc(ctx, accessToken)
+	}
+	return &social.GetFriendshipStatusResponse{}, nil
+}
+
+// Deauthorize implements social.LoginAPI.
+func (f *Fake) Deauthorize(ctx context.Context, channelAccessToken, userAccessToken string) (*social.BasicResponse, error) {
+	f.record("Deauthorize", channelAccessToken, userAccessToken)
+	if f.DeauthorizeFunc != nil {
+		return f.DeauthorizeFunc(ctx, channelAccessToken, userAccessToken)
+	}
+	return &social.BasicResponse{}, nil
+}
+
+// VerifyIDToken implements social.LoginAPI.
+func (f *Fake) VerifyIDToken(ctx context.Context, idToken string, options social.VerifyIDTokenRequestOptions) (*social.VerifyIDTokenResponse, error) {
+	f.record("VerifyIDToken", idToken, options.Nonce, options.UserID, options.ClientID)
+	if f.VerifyIDTokenFunc != nil {
+		return f.VerifyIDTokenFunc(ctx, idToken, options)
+	}
+	return &social.VerifyIDTokenResponse{}, nil
+}
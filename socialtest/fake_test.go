@@ -0,0 +1,51 @@
+package socialtest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	social "github.com/kkdai/line-login-sdk-go"
+)
+
+func TestFakeRecordsCalls(t *testing.T) {
+	fake := NewFake()
+	wantErr := errors.New("boom")
+	fake.RevokeFunc = func(ctx context.Context, accessToken string) (*social.BasicResponse, error) {
+		return nil, wantErr
+	}
+
+	var api social.LoginAPI = fake
+	ctx := context.Background()
+	if _, err := api.ExchangeCode(ctx, "https://example.com/cb", "code", ""); err != nil {
+		t.Errorf("ExchangeCode err: %v", err)
+	}
+	if _, err := api.Revoke(ctx, "token"); err != wantErr {
+		t.Errorf("Revoke err = %v, want %v", err, wantErr)
+	}
+
+	calls := fake.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("len(calls) = %d, want 2", len(calls))
+	}
+	if calls[0].Method != "ExchangeCode" || calls[0].Args[1] != "code" {
+		t.Errorf("calls[0] = %+v", calls[0])
+	}
+	if got := fake.CallsTo("Revoke"); len(got) != 1 || got[0].Args[0] != "token" {
+		t.Errorf("CallsTo(Revoke) = %+v", got)
+	}
+
+	options := social.VerifyIDTokenRequestOptions{Nonce: "nonce", UserID: "U1234", ClientID: "5678"}
+	if _, err := api.VerifyIDToken(ctx, "id-token", options); err != nil {
+		t.Errorf("VerifyIDToken err: %v", err)
+	}
+	if got := fake.CallsTo("VerifyIDToken"); len(got) != 1 || !reflect.DeepEqual(got[0].Args, []string{"id-token", "nonce", "U1234", "5678"}) {
+		t.Errorf("CallsTo(VerifyIDToken) = %+v", got)
+	}
+
+	fake.Reset()
+	if len(fake.Calls()) != 0 {
+		t.Errorf("Reset did not clear calls")
+	}
+}
@@ -0,0 +1,99 @@
+package socialtest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	social "github.com/kkdai/line-login-sdk-go"
+)
+
+func TestRecorderRecordAndReplay(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case social.APIEndpointToken:
+			fmt.Fprint(w, `{"access_token":"secret-access","refresh_token":"secret-refresh","expires_in":2592000,"token_type":"Bearer","scope":"profile"}`)
+		case social.APIEndpointGetUserProfile:
+			fmt.Fprint(w, `{"userId":"U1234","displayName":"Brown"}`)
+		case social.APIEndpointTokenVerify:
+			fmt.Fprint(w, `{"iss":"https://access.line.me","sub":"U1234","aud":"1234","nonce":"nonce-1"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	fixturePath := filepath.Join(t.TempDir(), "fixtures", "login.json")
+
+	rec, err := NewRecorder(fixturePath, ModeAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Mode() != ModeRecord {
+		t.Fatalf("Mode() = %v, want ModeRecord", rec.Mode())
+	}
+	client, err := social.New("1234", "channel-secret", social.WithHTTPClient(rec.Client()), social.WithEndpointBase(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetAccessToken("https://example.com/cb", "auth-code").Do(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetUserProfile("secret-access").Do(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.VerifyIDToken("secret-id-token", social.VerifyIDTokenRequestOptions{Nonce: "nonce-1"}).Do(); err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+
+	data, err := os.ReadFile(fixturePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"channel-secret", "auth-code", "secret-access", "secret-refresh", "secret-id-token"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("fixture contains %q", secret)
+		}
+	}
+
+	rec, err = NewRecorder(fixturePath, ModeAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Mode() != ModeReplay {
+		t.Fatalf("Mode() = %v, want ModeReplay", rec.Mode())
+	}
+	client, _ = social.New("1234", "another-secret", social.WithHTTPClient(rec.Client()), social.WithEndpointBase(srv.URL))
+	token, err := client.GetAccessToken("https://example.com/cb", "another-code").Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != Redacted || token.ExpiresIn != 2592000 {
+		t.Errorf("replayed token = %+v", token)
+	}
+	profile, err := client.GetUserProfile(token.AccessToken).Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.UserID != "U1234" {
+		t.Errorf("UserID = %s, want U1234", profile.UserID)
+	}
+
+	// A newly generated nonce matches the recorded one.
+	if payload, err := client.VerifyIDToken("another-id-token", social.VerifyIDTokenRequestOptions{Nonce: "nonce-2"}).Do(); err != nil || payload.Sub != "U1234" {
+		t.Errorf("VerifyIDToken = %+v, %v", payload, err)
+	}
+
+	// The interactions are used up.
+	if _, err := client.GetUserProfile(token.AccessToken).Do(); !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("err = %v, want ErrNoInteraction", err)
+	}
+}
@@ -0,0 +1,263 @@
+package social
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultStateTTL is how long a login state stays valid, matching the
+// lifetime of an authorization code.
+const DefaultStateTTL = 10 * time.Minute
+
+// LoginState is what an app remembers between the authorization request and
+// the callback.
+type LoginState struct {
+	State        string `json:"state"`
+	Nonce        string `json:"nonce,omitempty"`
+	CodeVerifier string `json:"code_verifier,omitempty"`
+	RedirectURI  string `json:"redirect_uri"`
+	// ReturnTo is a value from SignReturnTo, or empty.
+	ReturnTo string `json:"return_to,omitempty"`
+	// BotPrompt is the bot_prompt mode of the request, or empty.
+	BotPrompt string `json:"bot_prompt,omitempty"`
+	// Binding is the SHA-256 of LoginRequest.Binding.
+	Binding   string    `json:"binding"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// StateStore keeps login states until the callback arrives.
+type StateStore interface {
+	// Save stores a login state under s.State.
+	Save(ctx context.Context, s *LoginState) error
+	// Consume returns and deletes the login state. It returns ErrStateNotFound
+	// when the state is unknown, already consumed or expired.
+	Consume(ctx context.Context, state string) (*LoginState, error)
+}
+
+// MemoryStateStore is a StateStore for a single process.
+type MemoryStateStore struct {
+	mu     sync.Mutex
+	states map[string]*LoginState
+	now    func() time.Time
+}
+
+// NewMemoryStateStore returns an empty MemoryStateStore.
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{
+		states: map[string]*LoginState{},
+		now:    time.Now,
+	}
+}
+
+// Save method
+func (m *MemoryStateStore) Save(ctx context.Context, s *LoginState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	for key, st := range m.states {
+		if !now.Before(st.ExpiresAt) {
+			delete(m.states, key)
+		}
+	}
+	saved := *s
+	m.states[s.State] = &saved
+	return nil
+}
+
+// Consume method
+func (m *MemoryStateStore) Consume(ctx context.Context, state string) (*LoginState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.states[state]
+	if !ok {
+		return nil, ErrStateNotFound
+	}
+	delete(m.states, state)
+	if !m.now().Before(s.ExpiresAt) {
+		return nil, ErrStateNotFound
+	}
+	return s, nil
+}
+
+// LoginRequest describes an authorization request started with BeginLogin.
+type LoginRequest struct {
+	// Binding identifies the browser that starts the login, e.g. the value
+	// from LoginBinding or a session ID. CompleteLogin requires the same
+	// value, which stops login CSRF.
+	Binding     string
+	RedirectURI string
+	Scope       string
+	// ReturnTo is the page to send the user back to after login. It must be a
+	// local path or on an origin allowed by the redirect policy.
+	ReturnTo string
+	// PKCE adds a code challenge to the request.
+	PKCE bool
+	// Options are passed to the authorization URL. A nonce is generated when
+	// Options.Nonce is empty.
+	Options AuthRequestOptions
+	// TTL of the login state. Default DefaultStateTTL.
+	TTL time.Duration
+}
+
+// LoginResult is returned by CompleteLogin.
+type LoginResult struct {
+	Token *TokenResponse
+	// Payload is the validated ID token, or nil without the openid scope.
+	Payload *LineProfilePlusPayload
+	State   *LoginState
+	// ReturnTo is the verified return_to URL, or empty when none was given or
+	// it has expired.
+	ReturnTo string
+	// FriendshipStatusChanged is the friendship_status_changed callback
+	// parameter, or nil when the request had no bot_prompt.
+	FriendshipStatusChanged *bool
+}
+
+// AuthorizationError is an error returned to the callback by the authorization server.
+type AuthorizationError struct {
+	Code        string
+	Description string
+}
+
+// Error method
+func (e *AuthorizationError) Error() string {
+	if e.Description == "" {
+		return "authorization failed: " + e.Code
+	}
+	return fmt.Sprintf("authorization failed: %s: %s", e.Code, e.Description)
+}
+
+// BeginLogin generates the state, nonce and optional PKCE verifier for a
+// login, saves them with the signed return_to in store and returns the
+// authorization URL to redirect the user to.
+func (client *Client) BeginLogin(ctx context.Context, store StateStore, req LoginRequest) (string, error) {
+	if req.Binding == "" {
+		return "", errors.New("login request has no binding")
+	}
+	ttl := req.TTL
+	if ttl <= 0 {
+		ttl = DefaultStateTTL
+	}
+	state, err := GenerateState()
+	if err != nil {
+		return "", err
+	}
+	options := req.Options
+	if options.Nonce == "" {
+		if options.Nonce, err = GenerateNonce(); err != nil {
+			return "", err
+		}
+	}
+	s := &LoginState{
+		State:       state,
+		Nonce:       options.Nonce,
+		RedirectURI: req.RedirectURI,
+		BotPrompt:   options.BotPrompt,
+		Binding:     hashBinding(req.Binding),
+		ExpiresAt:   time.Now().Add(ttl),
+	}
+	if req.ReturnTo != "" {
+		if s.ReturnTo, err = client.SignReturnTo(req.ReturnTo, ttl); err != nil {
+			return "", err
+		}
+	}
+
+	var authURL string
+	if req.PKCE {
+		if s.CodeVerifier, err = GenerateCodeVerifier(43); err != nil {
+			return "", err
+		}
+		authURL, err = client.GetPKCEWebLoinURL(req.RedirectURI, state, req.Scope, PkceChallenge(s.CodeVerifier), options)
+	} else {
+		authURL, err = client.GetWebLoinURL(req.RedirectURI, state, req.Scope, options)
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := store.Save(ctx, s); err != nil {
+		return "", err
+	}
+	return authURL, nil
+}
+
+// CompleteLogin handles the callback query of a login started with
+// BeginLogin. binding must be the LoginRequest.Binding of that browser. It
+// consumes the state, exchanges the code, validates the ID token nonce and
+// verifies the return_to value.
+func (client *Client) CompleteLogin(ctx context.Context, store StateStore, binding string, query url.Values) (*LoginResult, error) {
+	s, err := store.Consume(ctx, query.Get("state"))
+	if err != nil {
+		return nil, err
+	}
+	if binding == "" || !hmac.Equal([]byte(s.Binding), []byte(hashBinding(binding))) {
+		return nil, ErrStateBindingMismatch
+	}
+	if code := query.Get("error"); code != "" {
+		return nil, &AuthorizationError{Code: code, Description: query.Get("error_description")}
+	}
+
+	var token *TokenResponse
+	if s.CodeVerifier != "" {
+		token, err = client.GetAccessTokenPKCE(s.RedirectURI, query.Get("code"), s.CodeVerifier).WithContext(ctx).Do()
+	} else {
+		token, err = client.GetAccessToken(s.RedirectURI, query.Get("code")).WithContext(ctx).Do()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &LoginResult{Token: token, State: s}
+	if changed, err := strconv.ParseBool(query.Get("friendship_status_changed")); err == nil {
+		result.FriendshipStatusChanged = &changed
+	}
+	if token.IDToken != "" {
+		if result.Payload, err = client.ValidateIDToken(token.IDToken, s.Nonce).WithContext(ctx).Do(); err != nil {
+			return nil, err
+		}
+	}
+	if s.ReturnTo != "" {
+		// An expired return_to does not fail the login; the app falls back
+		// to its default page.
+		result.ReturnTo, _ = client.VerifyReturnTo(s.ReturnTo)
+	}
+	return result, nil
+}
+
+func hashBinding(binding string) string {
+	sum := sha256.Sum256([]byte(binding))
+	return hex.EncodeToString(sum[:])
+}
+
+// LoginBindingCookie is the cookie LoginBinding uses.
+const LoginBindingCookie = "line_login_binding"
+
+// LoginBinding returns a random value identifying the browser for
+// LoginRequest.Binding and CompleteLogin. It is kept in an HttpOnly cookie,
+// which is set on the first call.
+func LoginBinding(w http.ResponseWriter, r *http.Request) (string, error) {
+	if c, err := r.Cookie(LoginBindingCookie); err == nil && c.Value != "" {
+		return c.Value, nil
+	}
+	binding, err := GenerateState()
+	if err != nil {
+		return "", err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     LoginBindingCookie,
+		Value:    binding,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return binding, nil
+}
@@ -0,0 +1,158 @@
+package social
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// SweepOptions configures RevokeAll and VerifyAll.
+type SweepOptions struct {
+	// Parallelism is the number of concurrent requests. Default 8.
+	Parallelism int
+	// RatePerSecond limits the request rate. Zero means no limit.
+	RatePerSecond float64
+	// Summary, when set, is filled in when the sweep ends.
+	Summary *SweepSummary
+}
+
+// SweepResult is the outcome for one token. Results arrive in completion
+// order; Index is the token's position in the input.
+type SweepResult struct {
+	Index int
+	Token string
+	// Verify is set by VerifyAll for valid tokens.
+	Verify   *TokenVerifyResponse
+	Duration time.Duration
+}
+
+// SweepSummary counts the results of a sweep.
+type SweepSummary struct {
+	Processed int
+	Succeeded int
+	// Invalid tokens were rejected by the LINE Platform with 400, e.g.
+	// because they expired or were revoked.
+	Invalid int
+	// Failed requests ended with a network, 5xx or other error.
+	Failed   int
+	Duration time.Duration
+}
+
+// RevokeAll revokes every token of tokens with RevokeToken. Tokens are read
+// as they are needed, so the sequence may be arbitrarily long. Stopping the
+// iteration or cancelling ctx stops the sweep.
+func (client *Client) RevokeAll(ctx context.Context, tokens iter.Seq[string], options SweepOptions) iter.Seq2[SweepResult, error] {
+	return sweep(ctx, tokens, options, func(ctx context.Context, token string) (*TokenVerifyResponse, error) {
+		_, err := client.RevokeToken(token).WithContext(ctx).Do()
+		return nil, err
+	})
+}
+
+// VerifyAll verifies every token of tokens with TokenVerify. See RevokeAll.
+func (client *Client) VerifyAll(ctx context.Context, tokens iter.Seq[string], options SweepOptions) iter.Seq2[SweepResult, error] {
+	return sweep(ctx, tokens, options, func(ctx context.Context, token string) (*TokenVerifyResponse, error) {
+		return client.TokenVerify(token).WithContext(ctx).Do()
+	})
+}
+
+type sweepJob struct {
+	index int
+	token string
+}
+
+type sweepOutcome struct {
+	result SweepResult
+	err    error
+}
+
+func sweep(ctx context.Context, tokens iter.Seq[string], options SweepOptions, fn func(context.Context, string) (*TokenVerifyResponse, error)) iter.Seq2[SweepResult, error] {
+	return func(yield func(SweepResult, error) bool) {
+		start := time.Now()
+		summary := SweepSummary{}
+		defer func() {
+			summary.Duration = time.Since(start)
+			if options.Summary != nil {
+				*options.Summary = summary
+			}
+		}()
+
+		sweepCtx, stop := context.WithCancel(ctx)
+		defer stop()
+		parallelism := options.Parallelism
+		if parallelism <= 0 {
+			parallelism = 8
+		}
+		limiter := &rateLimiter{}
+		if options.RatePerSecond > 0 {
+			limiter = newRateLimiter(options.RatePerSecond)
+		}
+
+		jobs := make(chan sweepJob)
+		outcomes := make(chan sweepOutcome, parallelism)
+		var workers sync.WaitGroup
+		for i := 0; i < parallelism; i++ {
+			workers.Add(1)
+			go func() {
+				defer workers.Done()
+				for job := range jobs {
+					if err := limiter.wait(sweepCtx); err != nil {
+						return
+					}
+					t := time.Now()
+					res, err := fn(sweepCtx, job.token)
+					if sweepCtx.Err() != nil {
+						return
+					}
+					outcomes <- sweepOutcome{
+						result: SweepResult{Index: job.index, Token: job.token, Verify: res, Duration: time.Since(t)},
+						err:    err,
+					}
+				}
+			}()
+		}
+		go func() {
+			defer close(jobs)
+			i := 0
+			for token := range tokens {
+				select {
+				case jobs <- sweepJob{index: i, token: token}:
+				case <-sweepCtx.Done():
+					return
+				}
+				i++
+			}
+		}()
+		go func() {
+			workers.Wait()
+			close(outcomes)
+		}()
+		// Drain the workers when the consumer stops early.
+		defer func() {
+			stop()
+			for range outcomes {
+			}
+		}()
+
+		for o := range outcomes {
+			summary.Processed++
+			var apiErr *APIError
+			switch {
+			case o.err == nil:
+				summary.Succeeded++
+			case errors.As(o.err, &apiErr) && apiErr.Code == http.StatusBadRequest:
+				summary.Invalid++
+			default:
+				summary.Failed++
+			}
+			if !yield(o.result, o.err) {
+				return
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			yield(SweepResult{Index: -1}, err)
+		}
+	}
+}
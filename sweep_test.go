@@ -0,0 +1,91 @@
+package social
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func testTokens(n int) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for i := 0; i < n; i++ {
+			if !yield(fmt.Sprintf("token-%d", i)) {
+				return
+			}
+		}
+	}
+}
+
+func TestVerifyAll(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		token := r.URL.Query().Get("access_token")
+		if strings.HasSuffix(token, "7") {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":"invalid_request","error_description":"access token expired"}`)
+			return
+		}
+		fmt.Fprint(w, `{"scope":"profile","client_id":"1234","expires_in":100}`)
+	}))
+	defer srv.Close()
+	client, err := New("1234", "secret", WithEndpointBase(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var summary SweepSummary
+	seen := map[int]bool{}
+	for res, err := range client.VerifyAll(context.Background(), testTokens(100), SweepOptions{Parallelism: 4, Summary: &summary}) {
+		seen[res.Index] = true
+		if res.Token != fmt.Sprintf("token-%d", res.Index) {
+			t.Errorf("result %d has token %q", res.Index, res.Token)
+		}
+		if (err != nil) != strings.HasSuffix(res.Token, "7") {
+			t.Errorf("%s: err = %v", res.Token, err)
+		}
+		if err == nil && res.Verify.ClientID != "1234" {
+			t.Errorf("%s: Verify = %+v", res.Token, res.Verify)
+		}
+	}
+	if len(seen) != 100 || summary.Processed != 100 || summary.Succeeded != 90 || summary.Invalid != 10 || summary.Failed != 0 {
+		t.Errorf("seen %d, summary = %+v", len(seen), summary)
+	}
+
+	// Stopping the iteration stops the sweep.
+	calls.Store(0)
+	n := 0
+	for range client.RevokeAll(context.Background(), testTokens(1000000), SweepOptions{Parallelism: 2, Summary: &summary}) {
+		n++
+		if n == 5 {
+			break
+		}
+	}
+	if c := calls.Load(); c > 10 {
+		t.Errorf("%d requests after stopping at 5", c)
+	}
+	if summary.Processed != 5 {
+		t.Errorf("summary after break = %+v", summary)
+	}
+
+	// Cancelling the context ends the sweep with the context's error.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var last error
+	n = 0
+	for _, err := range client.RevokeAll(ctx, testTokens(1000000), SweepOptions{Parallelism: 2}) {
+		n++
+		if n == 3 {
+			cancel()
+		}
+		last = err
+	}
+	if last != context.Canceled {
+		t.Errorf("last error = %v, want context.Canceled", last)
+	}
+}
@@ -0,0 +1,103 @@
+package social
+
+import "time"
+
+// RefreshTokenValidity is how long a refresh token stays valid after its
+// access token expired.
+const RefreshTokenValidity = 10 * 24 * time.Hour
+
+// Token is an access token with absolute expiry times, for storing between
+// requests. Its JSON form is stable: fields are always written in the same
+// order and times are in UTC.
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	// IDToken is the ID token of the login. Refreshing keeps it.
+	IDToken string `json:"id_token,omitempty"`
+	Scope   string `json:"scope,omitempty"`
+
+	IssuedAt time.Time `json:"issued_at"`
+	Expiry   time.Time `json:"expiry"`
+	// RefreshExpiry is when RefreshToken stops working.
+	RefreshExpiry time.Time `json:"refresh_expiry"`
+
+	// Clock returns the current time. Default time.Now.
+	Clock func() time.Time `json:"-"`
+}
+
+// NewToken stamps a token response with the time of clock, or time.Now
+// when clock is nil.
+func NewToken(res *TokenResponse, clock func() time.Time) *Token {
+	t := &Token{
+		AccessToken:  res.AccessToken,
+		TokenType:    res.TokenType,
+		RefreshToken: res.RefreshToken,
+		IDToken:      res.IDToken,
+		Scope:        res.Scope,
+		Clock:        clock,
+	}
+	t.stamp(res.ExpiresIn)
+	return t
+}
+
+// NewTokenFromRefresh stamps a refresh response like NewToken. The token has
+// no ID token.
+func NewTokenFromRefresh(res *TokenRefreshResponse, clock func() time.Time) *Token {
+	t := &Token{Clock: clock}
+	t.Merge(res)
+	return t
+}
+
+// Merge replaces the access and refresh tokens with a refresh result and
+// stamps the new expiry times. The ID token is kept.
+func (t *Token) Merge(res *TokenRefreshResponse) {
+	t.AccessToken = res.AccessToken
+	if res.TokenType != "" {
+		t.TokenType = res.TokenType
+	}
+	if res.RefreshToken != "" {
+		t.RefreshToken = res.RefreshToken
+	}
+	if res.Scope != "" {
+		t.Scope = res.Scope
+	}
+	t.stamp(res.ExpiresIn)
+}
+
+func (t *Token) stamp(expiresIn int) {
+	t.IssuedAt = t.now().UTC().Truncate(time.Second)
+	t.Expiry = t.IssuedAt.Add(time.Duration(expiresIn) * time.Second)
+	t.RefreshExpiry = t.Expiry.Add(RefreshTokenValidity)
+}
+
+func (t *Token) now() time.Time {
+	if t.Clock != nil {
+		return t.Clock()
+	}
+	return time.Now()
+}
+
+// Valid reports whether the access token is set and not expired.
+func (t *Token) Valid() bool {
+	return t != nil && t.AccessToken != "" && t.now().Before(t.Expiry)
+}
+
+// ExpiresWithin reports whether the access token expires within d. A nil
+// token counts as expired.
+func (t *Token) ExpiresWithin(d time.Duration) bool {
+	return t == nil || !t.now().Add(d).Before(t.Expiry)
+}
+
+// CanRefresh reports whether the refresh token is set and not expired.
+func (t *Token) CanRefresh() bool {
+	return t != nil && t.RefreshToken != "" && t.now().Before(t.RefreshExpiry)
+}
+
+// Scopes returns the granted scopes.
+func (t *Token) Scopes() ScopeSet {
+	if t == nil {
+		return ScopeSet{}
+	}
+	return ParseScopes(t.Scope)
+}
@@ -0,0 +1,76 @@
+package social
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestToken(t *testing.T) {
+	now := time.Date(2024, 5, 1, 9, 0, 0, 500, time.FixedZone("JST", 9*60*60))
+	clock := func() time.Time { return now }
+	token := NewToken(&TokenResponse{
+		AccessToken:  "access-1",
+		ExpiresIn:    3600,
+		IDToken:      "id-token",
+		RefreshToken: "refresh-1",
+		Scope:        "profile openid",
+		TokenType:    "Bearer",
+	}, clock)
+
+	if !token.IssuedAt.Equal(now.Truncate(time.Second)) || !token.Expiry.Equal(token.IssuedAt.Add(time.Hour)) {
+		t.Errorf("IssuedAt = %v, Expiry = %v", token.IssuedAt, token.Expiry)
+	}
+	if !token.RefreshExpiry.Equal(token.Expiry.Add(RefreshTokenValidity)) {
+		t.Errorf("RefreshExpiry = %v", token.RefreshExpiry)
+	}
+	if !token.Valid() || token.ExpiresWithin(30*time.Minute) || !token.ExpiresWithin(time.Hour) {
+		t.Errorf("fresh token: Valid = %v", token.Valid())
+	}
+	if !token.Scopes().Has(ScopeOpenID) {
+		t.Errorf("Scopes = %v", token.Scopes())
+	}
+
+	now = now.Add(2 * time.Hour)
+	if token.Valid() || !token.CanRefresh() {
+		t.Errorf("expired token: Valid = %v, CanRefresh = %v", token.Valid(), token.CanRefresh())
+	}
+	token.Merge(&TokenRefreshResponse{AccessToken: "access-2", ExpiresIn: 3600, RefreshToken: "refresh-2"})
+	if token.AccessToken != "access-2" || token.RefreshToken != "refresh-2" || token.IDToken != "id-token" || token.Scope != "profile openid" {
+		t.Errorf("merged token = %+v", token)
+	}
+	if !token.Valid() {
+		t.Errorf("merged token is not valid")
+	}
+	now = now.Add(time.Hour + RefreshTokenValidity)
+	if token.CanRefresh() {
+		t.Errorf("CanRefresh after the refresh window")
+	}
+
+	var none *Token
+	if none.Valid() || !none.ExpiresWithin(time.Minute) || none.CanRefresh() || len(none.Scopes()) != 0 {
+		t.Errorf("nil token is usable")
+	}
+}
+
+func TestTokenJSON(t *testing.T) {
+	now := time.Date(2024, 5, 1, 9, 0, 0, 0, time.FixedZone("JST", 9*60*60))
+	token := NewToken(&TokenResponse{AccessToken: "a", ExpiresIn: 60, RefreshToken: "r"}, func() time.Time { return now })
+	data, err := json.Marshal(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"access_token":"a","refresh_token":"r","issued_at":"2024-05-01T00:00:00Z","expiry":"2024-05-01T00:01:00Z","refresh_expiry":"2024-05-11T00:01:00Z"}`
+	if string(data) != want {
+		t.Errorf("json = %s\nwant   %s", data, want)
+	}
+
+	var decoded Token
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	decoded.Clock = func() time.Time { return now }
+	if !decoded.Valid() || !decoded.Expiry.Equal(token.Expiry) {
+		t.Errorf("decoded = %+v", decoded)
+	}
+}
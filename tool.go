@@ -4,10 +4,16 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	b64 "encoding/base64"
+	"io"
+	"math"
 )
 
+// letterRunes is the RFC 7636 unreserved character set.
 var letterRunes = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-._~")
 
+// DefaultEntropyBits is the entropy of values from GenerateState and GenerateNonce.
+const DefaultEntropyBits = 128
+
 // PkceChallenge: base64-URL-encoded SHA256 hash of verifier, per rfc 7636
 func PkceChallenge(verifier string) string {
 	sum := sha256.Sum256([]byte(verifier))
@@ -17,34 +23,83 @@ func PkceChallenge(verifier string) string {
 
 // GenerateCodeVerifier: Generate code verifier (length 43~128) for PKCE.
 func GenerateCodeVerifier(length int) (string, error) {
+	return RandomGenerator{}.CodeVerifier(length)
+}
+
+// GenerateState generates an OAuth state value with DefaultEntropyBits of entropy.
+func GenerateState() (string, error) {
+	return RandomGenerator{}.State()
+}
+
+// GenerateNonce generates an ID token nonce with DefaultEntropyBits of entropy.
+func GenerateNonce() (string, error) {
+	return RandomGenerator{}.Nonce()
+}
+
+// RandomGenerator generates random strings over the RFC 7636 unreserved
+// alphabet. Characters are picked by rejection sampling, so every character
+// is equally likely. The zero value uses crypto/rand and DefaultEntropyBits.
+type RandomGenerator struct {
+	// Source of random bytes. Default crypto/rand.Reader. Tests may inject a
+	// deterministic reader.
+	Source io.Reader
+	// Bits is the entropy of State and Nonce values. Default DefaultEntropyBits.
+	Bits int
+}
+
+// State returns a random state value.
+func (g RandomGenerator) State() (string, error) {
+	return g.String(g.length())
+}
+
+// Nonce returns a random nonce value.
+func (g RandomGenerator) Nonce() (string, error) {
+	return g.String(g.length())
+}
+
+// CodeVerifier returns a PKCE code verifier. length is clamped to 43~128.
+func (g RandomGenerator) CodeVerifier(length int) (string, error) {
 	if length > 128 {
 		length = 128
 	}
 	if length < 43 {
 		length = 43
 	}
-	return randStringRunes(length)
+	return g.String(length)
 }
 
-func GenerateNonce() (string, error) {
-	randomStr, err := randStringRunes(8)
-	if err != nil {
-		return "", err
+// String returns n characters drawn uniformly from the unreserved alphabet.
+func (g RandomGenerator) String(n int) (string, error) {
+	source := g.Source
+	if source == nil {
+		source = rand.Reader
 	}
-	return b64.StdEncoding.EncodeToString([]byte(randomStr)), nil
-}
-
-func randStringRunes(n int) (string, error) {
-	var result []rune
 	letterRunesLen := len(letterRunes)
-	for range n {
-		var randomBytes [1]byte
-		_, err := rand.Read(randomBytes[:])
-		if err != nil {
+	// Bytes at or above limit would favour the first characters, so they
+	// are rejected.
+	limit := 256 - 256%letterRunesLen
+
+	result := make([]rune, 0, n)
+	buf := make([]byte, n)
+	for len(result) < n {
+		chunk := buf[:n-len(result)]
+		if _, err := io.ReadFull(source, chunk); err != nil {
 			return "", err
 		}
-		index := int(randomBytes[0]) % letterRunesLen
-		result = append(result, letterRunes[index])
+		for _, b := range chunk {
+			if int(b) < limit {
+				result = append(result, letterRunes[int(b)%letterRunesLen])
+			}
+		}
 	}
 	return string(result), nil
 }
+
+// length returns the number of characters needed for the configured entropy.
+func (g RandomGenerator) length() int {
+	bits := g.Bits
+	if bits <= 0 {
+		bits = DefaultEntropyBits
+	}
+	return int(math.Ceil(float64(bits) / math.Log2(float64(len(letterRunes)))))
+}
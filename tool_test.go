@@ -1,6 +1,7 @@
 package social
 
 import (
+	"bytes"
 	"strings"
 	"testing"
 )
@@ -30,3 +31,38 @@ func TestCodeVerifier(t *testing.T) {
 		t.Errorf("CodeVerifier Error: \ncodeVer=%s\n", cv1)
 	}
 }
+
+func TestRandomGeneratorRejectsBiasedBytes(t *testing.T) {
+	// 198 and above are rejected; 0, 65 and 66 map to 'a', '~' and 'a'.
+	g := RandomGenerator{Source: bytes.NewReader([]byte{198, 255, 0, 65, 66})}
+	s, err := g.String(3)
+	if err != nil {
+		t.Fatalf("String Error: %v", err)
+	}
+	if s != "a~a" {
+		t.Errorf("String = %q, want %q", s, "a~a")
+	}
+}
+
+func TestRandomGeneratorEntropy(t *testing.T) {
+	state, err := GenerateState()
+	if err != nil {
+		t.Fatalf("GenerateState Error: %v", err)
+	}
+	// ceil(128 / log2(66)) characters.
+	if len(state) != 22 {
+		t.Errorf("len(state) = %d, want 22", len(state))
+	}
+
+	nonce, err := RandomGenerator{Bits: 256}.Nonce()
+	if err != nil {
+		t.Fatalf("Nonce Error: %v", err)
+	}
+	if len(nonce) != 43 {
+		t.Errorf("len(nonce) = %d, want 43", len(nonce))
+	}
+
+	if _, err := (RandomGenerator{Source: bytes.NewReader(nil)}).State(); err == nil {
+		t.Errorf("State with an empty source succeeded")
+	}
+}
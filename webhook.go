@@ -0,0 +1,274 @@
+package social
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	b64 "encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Webhook event types relevant to LINE Login integrations
+const (
+	EventTypeFollow      = "follow"
+	EventTypeUnfollow    = "unfollow"
+	EventTypeAccountLink = "accountLink"
+	EventTypeUnlink      = "unlink"
+)
+
+// maxWebhookBody limits the size of a webhook request body.
+const maxWebhookBody = 1 << 20
+
+// ValidateSignature checks the X-Line-Signature header of a webhook request
+// against the body. It returns ErrInvalidSignature on mismatch.
+func ValidateSignature(channelSecret string, body []byte, signature string) error {
+	decoded, err := b64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	mac := hmac.New(sha256.New, []byte(channelSecret))
+	mac.Write(body)
+	if !hmac.Equal(decoded, mac.Sum(nil)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// EventSource type
+type EventSource struct {
+	Type    string `json:"type"`
+	UserID  string `json:"userId,omitempty"`
+	GroupID string `json:"groupId,omitempty"`
+	RoomID  string `json:"roomId,omitempty"`
+}
+
+// DeliveryContext type
+type DeliveryContext struct {
+	IsRedelivery bool `json:"isRedelivery"`
+}
+
+// WebhookEvent holds the fields common to all webhook events. Events of
+// other types are delivered as *WebhookEvent.
+type WebhookEvent struct {
+	Type            string          `json:"type"`
+	Mode            string          `json:"mode"`
+	Timestamp       int64           `json:"timestamp"`
+	Source          EventSource     `json:"source"`
+	WebhookEventID  string          `json:"webhookEventId"`
+	DeliveryContext DeliveryContext `json:"deliveryContext"`
+	ReplyToken      string          `json:"replyToken,omitempty"`
+}
+
+// Event is a parsed webhook event.
+type Event interface {
+	Common() *WebhookEvent
+}
+
+// Common method
+func (e *WebhookEvent) Common() *WebhookEvent {
+	return e
+}
+
+// FollowEvent is sent when a user adds the bot as a friend or unblocks it.
+type FollowEvent struct {
+	WebhookEvent
+	Follow struct {
+		IsUnblocked bool `json:"isUnblocked"`
+	} `json:"follow"`
+}
+
+// UnfollowEvent is sent when a user blocks the bot.
+type UnfollowEvent struct {
+	WebhookEvent
+}
+
+// AccountLinkEvent is sent when a user finished the account link dialog.
+// Pass Link to CompleteAccountLink.
+type AccountLinkEvent struct {
+	WebhookEvent
+	Link struct {
+		// Result is "ok" or "failed".
+		Result string `json:"result"`
+		Nonce  string `json:"nonce"`
+	} `json:"link"`
+}
+
+// UnlinkEvent is sent when a linked account is unlinked.
+type UnlinkEvent struct {
+	WebhookEvent
+}
+
+// WebhookPayload is the body of a webhook request.
+type WebhookPayload struct {
+	Destination string
+	Events      []Event
+}
+
+// ParseWebhook validates the signature of a webhook request against the
+// current and previous secrets of secrets and parses its events.
+func ParseWebhook(r *http.Request, secrets SecretProvider) (*WebhookPayload, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxWebhookBody {
+		return nil, fmt.Errorf("webhook body too large")
+	}
+	current, previous, err := secrets.Secrets()
+	if err != nil {
+		return nil, err
+	}
+	signature := r.Header.Get("X-Line-Signature")
+	err = ErrInvalidSignature
+	for _, secret := range append([]string{current}, previous...) {
+		if secret != "" && ValidateSignature(secret, body, signature) == nil {
+			err = nil
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseWebhookBody(body)
+}
+
+func parseWebhookBody(body []byte) (*WebhookPayload, error) {
+	var raw struct {
+		Destination string            `json:"destination"`
+		Events      []json.RawMessage `json:"events"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("webhook: %w", err)
+	}
+	payload := &WebhookPayload{Destination: raw.Destination}
+	for _, data := range raw.Events {
+		var common WebhookEvent
+		if err := json.Unmarshal(data, &common); err != nil {
+			return nil, fmt.Errorf("webhook: %w", err)
+		}
+		var event Event
+		switch common.Type {
+		case EventTypeFollow:
+			event = &FollowEvent{}
+		case EventTypeUnfollow:
+			event = &UnfollowEvent{}
+		case EventTypeAccountLink:
+			event = &AccountLinkEvent{}
+		case EventTypeUnlink:
+			event = &UnlinkEvent{}
+		default:
+			event = &common
+		}
+		if err := json.Unmarshal(data, event); err != nil {
+			return nil, fmt.Errorf("webhook: %s event: %w", common.Type, err)
+		}
+		payload.Events = append(payload.Events, event)
+	}
+	return payload, nil
+}
+
+// WebhookHandler is an http.Handler for the webhook URL of a Messaging API
+// channel. It rejects requests with an invalid signature and passes each
+// event to the handler registered for its type.
+type WebhookHandler struct {
+	secrets     SecretProvider
+	follow      []func(context.Context, *FollowEvent) error
+	unfollow    []func(context.Context, *UnfollowEvent) error
+	accountLink []func(context.Context, *AccountLinkEvent) error
+	unlink      []func(context.Context, *UnlinkEvent) error
+	other       []func(context.Context, Event) error
+}
+
+// NewWebhookHandler returns a handler verifying signatures with the
+// Messaging API channel secret, e.g. StaticSecret(secret).
+func NewWebhookHandler(secrets SecretProvider) *WebhookHandler {
+	return &WebhookHandler{secrets: secrets}
+}
+
+// OnFollow registers a handler for follow events.
+func (h *WebhookHandler) OnFollow(f func(context.Context, *FollowEvent) error) *WebhookHandler {
+	h.follow = append(h.follow, f)
+	return h
+}
+
+// OnUnfollow registers a handler for unfollow events.
+func (h *WebhookHandler) OnUnfollow(f func(context.Context, *UnfollowEvent) error) *WebhookHandler {
+	h.unfollow = append(h.unfollow, f)
+	return h
+}
+
+// OnAccountLink registers a handler for accountLink events.
+func (h *WebhookHandler) OnAccountLink(f func(context.Context, *AccountLinkEvent) error) *WebhookHandler {
+	h.accountLink = append(h.accountLink, f)
+	return h
+}
+
+// OnUnlink registers a handler for unlink events.
+func (h *WebhookHandler) OnUnlink(f func(context.Context, *UnlinkEvent) error) *WebhookHandler {
+	h.unlink = append(h.unlink, f)
+	return h
+}
+
+// OnOther registers a handler for events of all other types.
+func (h *WebhookHandler) OnOther(f func(context.Context, Event) error) *WebhookHandler {
+	h.other = append(h.other, f)
+	return h
+}
+
+// ServeHTTP answers 400 for invalid signatures or bodies, 500 when a
+// handler fails and 200 otherwise.
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	payload, err := ParseWebhook(r, h.secrets)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if err := h.Dispatch(r.Context(), payload); err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Dispatch passes the events of payload to the registered handlers. It
+// stops at the first error.
+func (h *WebhookHandler) Dispatch(ctx context.Context, payload *WebhookPayload) error {
+	for _, event := range payload.Events {
+		if err := h.dispatch(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *WebhookHandler) dispatch(ctx context.Context, event Event) error {
+	switch e := event.(type) {
+	case *FollowEvent:
+		return callEach(ctx, h.follow, e)
+	case *UnfollowEvent:
+		return callEach(ctx, h.unfollow, e)
+	case *AccountLinkEvent:
+		return callEach(ctx, h.accountLink, e)
+	case *UnlinkEvent:
+		return callEach(ctx, h.unlink, e)
+	default:
+		return callEach(ctx, h.other, event)
+	}
+}
+
+func callEach[E any](ctx context.Context, handlers []func(context.Context, E) error, event E) error {
+	for _, f := range handlers {
+		if err := f(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package social
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	b64 "encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testWebhookBody = `{"destination":"Uxxxxxxxx","events":[
+{"type":"follow","mode":"active","timestamp":1462629479859,"source":{"type":"user","userId":"U1234"},"webhookEventId":"01F","deliveryContext":{"isRedelivery":false},"replyToken":"r1","follow":{"isUnblocked":true}},
+{"type":"unfollow","mode":"active","timestamp":1462629479860,"source":{"type":"user","userId":"U5678"}},
+{"type":"accountLink","mode":"active","timestamp":1462629479861,"source":{"type":"user","userId":"U1234"},"link":{"result":"ok","nonce":"xxxxxxxxxxxxxxx"}},
+{"type":"message","mode":"active","timestamp":1462629479862,"source":{"type":"user","userId":"U1234"}}
+]}`
+
+func signWebhook(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return b64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestValidateSignature(t *testing.T) {
+	body := []byte(testWebhookBody)
+	if err := ValidateSignature("secret", body, signWebhook("secret", testWebhookBody)); err != nil {
+		t.Errorf("ValidateSignature = %v", err)
+	}
+	for _, sig := range []string{signWebhook("other", testWebhookBody), "", "not base64!"} {
+		if err := ValidateSignature("secret", body, sig); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("ValidateSignature(%q) = %v, want ErrInvalidSignature", sig, err)
+		}
+	}
+}
+
+func TestWebhookHandler(t *testing.T) {
+	var got []string
+	h := NewWebhookHandler(StaticSecret("new", "old")).
+		OnFollow(func(ctx context.Context, e *FollowEvent) error {
+			got = append(got, "follow:"+e.Source.UserID)
+			if !e.Follow.IsUnblocked || e.ReplyToken != "r1" {
+				t.Errorf("follow event = %+v", e)
+			}
+			return nil
+		}).
+		OnUnfollow(func(ctx context.Context, e *UnfollowEvent) error {
+			got = append(got, "unfollow:"+e.Source.UserID)
+			return nil
+		}).
+		OnAccountLink(func(ctx context.Context, e *AccountLinkEvent) error {
+			got = append(got, "accountLink:"+e.Link.Result+":"+e.Link.Nonce)
+			return nil
+		}).
+		OnOther(func(ctx context.Context, e Event) error {
+			got = append(got, "other:"+e.Common().Type)
+			return nil
+		})
+
+	tests := []struct {
+		secret string
+		status int
+	}{
+		{"new", http.StatusOK},
+		{"old", http.StatusOK},
+		{"wrong", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		got = nil
+		req := httptest.NewRequest("POST", "/webhook", strings.NewReader(testWebhookBody))
+		req.Header.Set("X-Line-Signature", signWebhook(tt.secret, testWebhookBody))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tt.status {
+			t.Errorf("secret %q: status = %d, want %d", tt.secret, rec.Code, tt.status)
+		}
+		want := "follow:U1234 unfollow:U5678 accountLink:ok:xxxxxxxxxxxxxxx other:message"
+		if tt.status != http.StatusOK {
+			want = ""
+		}
+		if strings.Join(got, " ") != want {
+			t.Errorf("secret %q: events = %v", tt.secret, got)
+		}
+	}
+
+	h.OnUnfollow(func(ctx context.Context, e *UnfollowEvent) error { return errors.New("db down") })
+	req := httptest.NewRequest("POST", "/webhook", strings.NewReader(testWebhookBody))
+	req.Header.Set("X-Line-Signature", signWebhook("new", testWebhookBody))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status with failing handler = %d", rec.Code)
+	}
+}